@@ -0,0 +1,356 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package client4 implements a DHCPv4 client following the state machine
+// described in RFC 2131 section 4.4: INIT -> SELECTING -> REQUESTING ->
+// BOUND -> RENEWING -> REBINDING, including DECLINE, RELEASE and INFORM.
+// Discover/Request/Renew/Rebind are exposed individually for scripted use
+// (see Scenario), and Maintain drives all of them automatically off the
+// T1/T2 timers in LeaseTimers for long-running clients. It is usable both
+// as a library and as the backing implementation of the `coredhcp-client4`
+// CLI in cmds/client/v4.
+//
+// Client is transport-agnostic: it sends and receives through the Conn
+// interface, which has two implementations. NewUDPConn binds an ordinary
+// UDP socket and is the right choice whenever the interface already has
+// an IP address (e.g. a RENEWING client talking to its current lessor).
+// NewRawConn instead opens a Linux AF_PACKET socket and builds its own
+// Ethernet/IPv4/UDP frames, for the common PXE-boot case of a client with
+// no address at all on the interface yet.
+package client4
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+// State is a DHCPv4 client state, per RFC 2131 section 4.4.
+type State int
+
+const (
+	StateInit State = iota
+	StateSelecting
+	StateRequesting
+	StateBound
+	StateRenewing
+	StateRebinding
+)
+
+func (s State) String() string {
+	switch s {
+	case StateInit:
+		return "INIT"
+	case StateSelecting:
+		return "SELECTING"
+	case StateRequesting:
+		return "REQUESTING"
+	case StateBound:
+		return "BOUND"
+	case StateRenewing:
+		return "RENEWING"
+	case StateRebinding:
+		return "REBINDING"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// defaultRetries is the number of times a message is sent, counting the
+// first transmission, before an exchange (DISCOVER, REQUEST, ...) gives up.
+const defaultRetries = 4
+
+// Client implements the DHCPv4 client state machine over a Conn.
+type Client struct {
+	Conn    Conn
+	MAC     net.HardwareAddr
+	Timeout time.Duration
+
+	state State
+	lease *dhcpv4.DHCPv4 // most recent ACK
+}
+
+// New returns a Client that sends and receives over conn, identifying
+// itself with mac. A zero timeout defaults to 5 seconds per
+// retransmission attempt.
+func New(conn Conn, mac net.HardwareAddr, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Client{Conn: conn, MAC: mac, Timeout: timeout, state: StateInit}
+}
+
+// State reports the client's current RFC 2131 state.
+func (c *Client) State() State { return c.state }
+
+// Lease reports the most recently acknowledged lease, or nil if the
+// client is not currently bound.
+func (c *Client) Lease() *dhcpv4.DHCPv4 { return c.lease }
+
+// Discover broadcasts a DISCOVER and returns the first OFFER received.
+func (c *Client) Discover() (*dhcpv4.DHCPv4, error) {
+	c.state = StateSelecting
+	discover, err := dhcpv4.NewDiscovery(c.MAC, dhcpv4.WithBroadcast(c.Conn.NeedsBroadcast()))
+	if err != nil {
+		return nil, fmt.Errorf("building DISCOVER: %w", err)
+	}
+	offer, err := c.exchange(discover, broadcastAddr(), dhcpv4.MessageTypeOffer)
+	if err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+// Request sends a broadcast REQUEST selecting offer, per the SELECTING
+// state in RFC 2131, and returns the resulting ACK (or NAK, as an error).
+func (c *Client) Request(offer *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, error) {
+	c.state = StateRequesting
+	req, err := dhcpv4.NewRequestFromOffer(offer, dhcpv4.WithBroadcast(c.Conn.NeedsBroadcast()))
+	if err != nil {
+		return nil, fmt.Errorf("building REQUEST: %w", err)
+	}
+	return c.finishRequest(req, broadcastAddr())
+}
+
+// Renew performs a RENEWING-state unicast REQUEST directly to the server
+// that issued the current lease, as required once T1 has elapsed.
+func (c *Client) Renew() (*dhcpv4.DHCPv4, error) {
+	lease := c.lease
+	if lease == nil {
+		return nil, fmt.Errorf("no active lease to renew")
+	}
+	c.state = StateRenewing
+	req, err := renewalRequest(c.MAC, lease)
+	if err != nil {
+		return nil, err
+	}
+	serverIP := lease.ServerIdentifier()
+	if serverIP == nil {
+		return nil, fmt.Errorf("lease has no server identifier, cannot unicast renew")
+	}
+	return c.finishRequest(req, &net.UDPAddr{IP: serverIP, Port: 67})
+}
+
+// Rebind performs a REBINDING-state broadcast REQUEST, used once T2 has
+// elapsed without a successful Renew.
+func (c *Client) Rebind() (*dhcpv4.DHCPv4, error) {
+	lease := c.lease
+	if lease == nil {
+		return nil, fmt.Errorf("no active lease to rebind")
+	}
+	c.state = StateRebinding
+	req, err := renewalRequest(c.MAC, lease)
+	if err != nil {
+		return nil, err
+	}
+	return c.finishRequest(req, broadcastAddr())
+}
+
+func (c *Client) finishRequest(req *dhcpv4.DHCPv4, dst *net.UDPAddr) (*dhcpv4.DHCPv4, error) {
+	ack, err := c.exchange(req, dst, dhcpv4.MessageTypeAck)
+	if err != nil {
+		return nil, err
+	}
+	if ack.MessageType() == dhcpv4.MessageTypeNak {
+		c.state = StateInit
+		c.lease = nil
+		return ack, fmt.Errorf("server NAKed request: %s", ack.Summary())
+	}
+	c.lease = ack
+	c.state = StateBound
+	return ack, nil
+}
+
+// renewalRequest builds the unicast/broadcast REQUEST used in the
+// RENEWING and REBINDING states: ciaddr is set to the leased address and,
+// unlike the SELECTING-state REQUEST, neither requested-IP (option 50)
+// nor server-identifier (option 54) are included.
+func renewalRequest(mac net.HardwareAddr, lease *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, error) {
+	return dhcpv4.New(
+		dhcpv4.WithHwAddr(mac),
+		dhcpv4.WithTransactionID(dhcpv4.GenerateTransactionID()),
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeRequest),
+		dhcpv4.WithClientIP(lease.YourIPAddr),
+	)
+}
+
+// Decline tells the server that offer's address failed a conflict check
+// (e.g. ARP probe) and must not be offered again.
+func (c *Client) Decline(offer *dhcpv4.DHCPv4, reason string) error {
+	decline, err := dhcpv4.New(
+		dhcpv4.WithHwAddr(c.MAC),
+		dhcpv4.WithTransactionID(dhcpv4.GenerateTransactionID()),
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeDecline),
+		dhcpv4.WithOption(dhcpv4.OptRequestedIPAddress(offer.YourIPAddr)),
+		dhcpv4.WithOption(dhcpv4.OptServerIdentifier(offer.ServerIdentifier())),
+	)
+	if err != nil {
+		return fmt.Errorf("building DECLINE: %w", err)
+	}
+	if reason != "" {
+		decline.UpdateOption(dhcpv4.OptMessage(reason))
+	}
+	c.state = StateInit
+	c.lease = nil
+	return c.Conn.SendTo(broadcastAddr(), decline.ToBytes())
+}
+
+// Release gives up the current lease, unicasting a RELEASE to the
+// lessor. The client has no further use for the address after this call.
+func (c *Client) Release() error {
+	lease := c.lease
+	if lease == nil {
+		return fmt.Errorf("no active lease to release")
+	}
+	serverIP := lease.ServerIdentifier()
+	if serverIP == nil {
+		return fmt.Errorf("lease has no server identifier, cannot unicast release")
+	}
+	release, err := dhcpv4.New(
+		dhcpv4.WithHwAddr(c.MAC),
+		dhcpv4.WithTransactionID(dhcpv4.GenerateTransactionID()),
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeRelease),
+		dhcpv4.WithClientIP(lease.YourIPAddr),
+		dhcpv4.WithOption(dhcpv4.OptServerIdentifier(serverIP)),
+	)
+	if err != nil {
+		return fmt.Errorf("building RELEASE: %w", err)
+	}
+	err = c.Conn.SendTo(&net.UDPAddr{IP: serverIP, Port: 67}, release.ToBytes())
+	c.state = StateInit
+	c.lease = nil
+	return err
+}
+
+// Inform requests additional configuration (options only, no lease) for
+// an address the client has already configured by some other means.
+func (c *Client) Inform(clientIP net.IP) (*dhcpv4.DHCPv4, error) {
+	inform, err := dhcpv4.New(
+		dhcpv4.WithHwAddr(c.MAC),
+		dhcpv4.WithTransactionID(dhcpv4.GenerateTransactionID()),
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeInform),
+		dhcpv4.WithClientIP(clientIP),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("building INFORM: %w", err)
+	}
+	return c.exchange(inform, broadcastAddr(), dhcpv4.MessageTypeAck)
+}
+
+// exchange sends req to dst, waiting up to c.Timeout for a reply after each
+// send, and resends until a reply of the given message type (or a NAK) is
+// received or retries are exhausted. There is no backoff between attempts.
+func (c *Client) exchange(req *dhcpv4.DHCPv4, dst *net.UDPAddr, want dhcpv4.MessageType) (*dhcpv4.DHCPv4, error) {
+	var lastErr error
+	for attempt := 0; attempt < defaultRetries; attempt++ {
+		if err := c.Conn.SendTo(dst, req.ToBytes()); err != nil {
+			return nil, fmt.Errorf("sending %s: %w", req.MessageType(), err)
+		}
+		raw, err := c.Conn.Recv(c.Timeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp, err := dhcpv4.FromBytes(raw)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.TransactionID != req.TransactionID {
+			continue
+		}
+		if resp.MessageType() != want && resp.MessageType() != dhcpv4.MessageTypeNak {
+			continue
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("no %s received after %d attempts: %w", want, defaultRetries, lastErr)
+}
+
+func broadcastAddr() *net.UDPAddr {
+	return &net.UDPAddr{IP: net.IPv4bcast, Port: 67}
+}
+
+// LeaseTimers returns the RFC 2131 T1 (renewal) and T2 (rebinding)
+// durations carried in ack, falling back to the conventional 50%/87.5% of
+// the lease time when the server did not supply them explicitly.
+func LeaseTimers(ack *dhcpv4.DHCPv4) (t1, t2, lease time.Duration) {
+	lease = ack.IPAddressLeaseTime(0)
+	t1 = ack.IPAddressRenewalTime(lease / 2)
+	t2 = ack.IPAddressRebindingTime(lease * 7 / 8)
+	return t1, t2, lease
+}
+
+// Maintain drives the full RFC 2131 lifecycle until stop is closed: it
+// discovers and requests a lease, sleeps until T1 and unicasts a Renew,
+// falls back to a broadcast Rebind at T2 if the server didn't answer, and
+// starts over from Discover if Rebind also fails. It returns only when
+// stop is closed or a step fails in a way that isn't retryable (a DECLINE
+// is never sent automatically, since that requires an address-conflict
+// check Maintain has no opinion on).
+func (c *Client) Maintain(stop <-chan struct{}) error {
+	for {
+		offer, err := c.Discover()
+		if err != nil {
+			return fmt.Errorf("maintain: %w", err)
+		}
+		ack, err := c.Request(offer)
+		if err != nil {
+			return fmt.Errorf("maintain: %w", err)
+		}
+		stopped, err := c.maintainLease(ack, stop)
+		if err != nil {
+			return err
+		}
+		if stopped {
+			return nil
+		}
+	}
+}
+
+// maintainLease renews/rebinds the lease acknowledged by ack, looping
+// (rather than recursing, which would grow the stack without bound over a
+// long-running process's lifetime) onto each fresh ack's own timers as
+// Renew/Rebind succeed. It returns (true, nil) once stop is closed, or
+// (false, nil) to ask Maintain to restart from Discover once both Renew
+// and Rebind have failed.
+func (c *Client) maintainLease(ack *dhcpv4.DHCPv4, stop <-chan struct{}) (stopped bool, err error) {
+	for {
+		t1, t2, _ := LeaseTimers(ack)
+
+		select {
+		case <-stop:
+			return true, nil
+		case <-time.After(jitter(t1)):
+		}
+		if renewed, err := c.Renew(); err == nil {
+			ack = renewed
+			continue
+		}
+
+		select {
+		case <-stop:
+			return true, nil
+		case <-time.After(jitter(t2 - t1)):
+		}
+		rebound, err := c.Rebind()
+		if err != nil {
+			return false, nil
+		}
+		ack = rebound
+	}
+}
+
+// jitter returns d +/- up to 10%, to avoid a thundering herd of clients
+// all renewing at exactly T1.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	spread := float64(d) * 0.1
+	return d + time.Duration(rand.Float64()*2*spread-spread)
+}
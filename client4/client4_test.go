@@ -0,0 +1,209 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package client4
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildAndParseEthernetIPv4UDP(t *testing.T) {
+	src := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	payload := []byte("hello dhcp")
+
+	frame, err := buildEthernetIPv4UDP(src, broadcastMAC, net.IPv4zero, net.IPv4bcast, 68, 67, payload)
+	require.NoError(t, err)
+
+	got, dstPort, ok := parseEthernetIPv4UDP(frame)
+	require.True(t, ok)
+	assert.Equal(t, uint16(67), dstPort)
+	assert.Equal(t, payload, got)
+}
+
+func TestParseScenario(t *testing.T) {
+	doc := []byte(`
+steps:
+  - discover
+  - expect: offer
+  - request
+  - expect: ack
+  - sleep: 5ms
+  - renew
+`)
+	sc, err := ParseScenario(doc)
+	require.NoError(t, err)
+	require.Len(t, sc.Steps, 6)
+	assert.Equal(t, "discover", sc.Steps[0].Action)
+	assert.Equal(t, "expect", sc.Steps[1].Action)
+	assert.Equal(t, "offer", sc.Steps[1].Arg)
+	assert.Equal(t, "sleep", sc.Steps[4].Action)
+	assert.Equal(t, "5ms", sc.Steps[4].Arg)
+}
+
+func TestParseScenarioUnknownStep(t *testing.T) {
+	_, err := ParseScenario([]byte("steps:\n  - 42\n"))
+	// A bare integer is neither a string nor a single-key map, so parsing
+	// the step itself should fail.
+	assert.Error(t, err)
+}
+
+func TestStateString(t *testing.T) {
+	assert.Equal(t, "BOUND", StateBound.String())
+	assert.Equal(t, "UNKNOWN", State(99).String())
+}
+
+// scriptedConn answers each SendTo with the OFFER/ACK that would follow it
+// in a normal exchange, so Maintain can run end-to-end against it without a
+// real socket. leaseTime controls the lease handed out on each ACK
+// (defaulting to an hour) and onRequest, if set, is called with the
+// running count of REQUESTs (covering both the initial REQUEST and every
+// subsequent Renew/Rebind) after each one is sent.
+type scriptedConn struct {
+	lastSent     *dhcpv4.DHCPv4
+	leaseTime    time.Duration
+	requestCount int
+	onRequest    func(count int)
+}
+
+func (s *scriptedConn) SendTo(_ *net.UDPAddr, b []byte) error {
+	req, err := dhcpv4.FromBytes(b)
+	if err != nil {
+		return err
+	}
+	s.lastSent = req
+	if req.MessageType() == dhcpv4.MessageTypeRequest {
+		s.requestCount++
+		if s.onRequest != nil {
+			s.onRequest(s.requestCount)
+		}
+	}
+	return nil
+}
+
+func (s *scriptedConn) Recv(time.Duration) ([]byte, error) {
+	leaseTime := s.leaseTime
+	if leaseTime <= 0 {
+		leaseTime = time.Hour
+	}
+	var opts []dhcpv4.Modifier
+	switch s.lastSent.MessageType() {
+	case dhcpv4.MessageTypeDiscover:
+		opts = []dhcpv4.Modifier{dhcpv4.WithMessageType(dhcpv4.MessageTypeOffer)}
+	case dhcpv4.MessageTypeRequest:
+		opts = []dhcpv4.Modifier{
+			dhcpv4.WithMessageType(dhcpv4.MessageTypeAck),
+			dhcpv4.WithOption(dhcpv4.OptIPAddressLeaseTime(leaseTime)),
+		}
+	default:
+		return nil, fmt.Errorf("scriptedConn: unexpected message type %s", s.lastSent.MessageType())
+	}
+	opts = append(opts, dhcpv4.WithYourIP(net.IPv4(192, 168, 1, 100)), dhcpv4.WithOption(dhcpv4.OptServerIdentifier(net.IPv4(192, 168, 1, 1))))
+	reply, err := dhcpv4.NewReplyFromRequest(s.lastSent, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return reply.ToBytes(), nil
+}
+
+func (s *scriptedConn) Close() error { return nil }
+
+// NeedsBroadcast is false: these tests exercise the state machine over an
+// in-memory stub, not a real raw socket.
+func (s *scriptedConn) NeedsBroadcast() bool { return false }
+
+func TestMaintainReturnsWhenStopAlreadyClosed(t *testing.T) {
+	mac := mustParseMAC(t, "00:11:22:33:44:77")
+	c := New(&scriptedConn{}, mac, time.Second)
+
+	stop := make(chan struct{})
+	close(stop)
+
+	done := make(chan error, 1)
+	go func() { done <- c.Maintain(stop) }()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Maintain did not return promptly after stop was closed")
+	}
+	assert.Equal(t, StateBound, c.State())
+	require.NotNil(t, c.Lease())
+}
+
+// TestMaintainLeaseLoopsIteratively drives many renew cycles through a very
+// short lease time. maintainLease loops rather than recursing, so this
+// should complete regardless of how many cycles run; a recursive
+// implementation would eventually blow the goroutine stack instead.
+func TestMaintainLeaseLoopsIteratively(t *testing.T) {
+	const cycles = 200
+	mac := mustParseMAC(t, "00:11:22:33:44:99")
+	conn := &scriptedConn{leaseTime: 5 * time.Millisecond}
+	c := New(conn, mac, time.Second)
+
+	stop := make(chan struct{})
+	conn.onRequest = func(count int) {
+		// == rather than >=: a renew already in flight when stop closes
+		// would otherwise close it a second time.
+		if count == cycles {
+			close(stop)
+		}
+	}
+
+	offer, err := c.Discover()
+	require.NoError(t, err)
+	ack, err := c.Request(offer)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		stopped, err := c.maintainLease(ack, stop)
+		if err != nil {
+			done <- err
+			return
+		}
+		if !stopped {
+			done <- fmt.Errorf("maintainLease returned false (gave up) instead of stopping")
+			return
+		}
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("maintainLease did not complete enough renew cycles in time")
+	}
+	assert.GreaterOrEqual(t, conn.requestCount, cycles)
+}
+
+// TestDiscoverRequestBroadcastFlag checks that the RFC 2131 broadcast flag
+// follows the Conn's NeedsBroadcast, not a hardcoded true.
+func TestDiscoverRequestBroadcastFlag(t *testing.T) {
+	mac := mustParseMAC(t, "00:11:22:33:44:aa")
+	conn := &scriptedConn{}
+	c := New(conn, mac, time.Second)
+
+	offer, err := c.Discover()
+	require.NoError(t, err)
+	assert.False(t, conn.lastSent.IsBroadcast(), "Discover over a NeedsBroadcast()==false Conn should not set the broadcast flag")
+
+	_, err = c.Request(offer)
+	require.NoError(t, err)
+	assert.False(t, conn.lastSent.IsBroadcast(), "Request over a NeedsBroadcast()==false Conn should not set the broadcast flag")
+}
+
+func mustParseMAC(t *testing.T, s string) net.HardwareAddr {
+	mac, err := net.ParseMAC(s)
+	require.NoError(t, err)
+	return mac
+}
@@ -0,0 +1,31 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package client4
+
+import (
+	"net"
+	"time"
+)
+
+// Conn abstracts the transport a Client sends and receives DHCPv4 packets
+// over. The default implementation (see conn_udp.go) is a plain UDP
+// socket bound to port 68; when the interface has no IP address at all
+// (as is typical right after PXE boot), NewRawConn provides an
+// AF_PACKET-based implementation that needs no local address.
+type Conn interface {
+	// SendTo transmits b to addr. addr.IP may be the IPv4 broadcast
+	// address.
+	SendTo(addr *net.UDPAddr, b []byte) error
+	// Recv blocks for up to timeout waiting for a packet and returns its
+	// payload.
+	Recv(timeout time.Duration) ([]byte, error)
+	// Close releases the underlying socket.
+	Close() error
+	// NeedsBroadcast reports whether outgoing DISCOVER/REQUEST messages
+	// must set the RFC 2131 broadcast flag so the server's reply is
+	// broadcast back, because this Conn has no local address a unicast
+	// reply could be addressed to.
+	NeedsBroadcast() bool
+}
@@ -0,0 +1,119 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+//go:build linux
+
+package client4
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// rawConn is an AF_PACKET/SOCK_RAW based Conn that builds and parses its
+// own Ethernet/IPv4/UDP frames, so a client can run with `--iface eth0`
+// on an interface that has no IP address configured at all, as is the
+// case right after a PXE boot.
+type rawConn struct {
+	fd        int
+	ifIndex   int
+	localMAC  net.HardwareAddr
+	localAddr *unix.SockaddrLinklayer
+}
+
+// NewRawConn opens a raw AF_PACKET socket bound to iface, filtering for
+// IPv4/UDP frames on send and recv. The caller must be privileged enough
+// to open raw sockets (typically CAP_NET_RAW or root).
+func NewRawConn(iface string) (Conn, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("looking up interface %q: %w", iface, err)
+	}
+
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, htons(unix.ETH_P_IP))
+	if err != nil {
+		return nil, fmt.Errorf("opening AF_PACKET socket (are you root?): %w", err)
+	}
+
+	addr := &unix.SockaddrLinklayer{
+		Protocol: htons(unix.ETH_P_IP),
+		Ifindex:  ifi.Index,
+	}
+	if err := unix.Bind(fd, addr); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("binding AF_PACKET socket to %q: %w", iface, err)
+	}
+
+	return &rawConn{
+		fd:        fd,
+		ifIndex:   ifi.Index,
+		localMAC:  ifi.HardwareAddr,
+		localAddr: addr,
+	}, nil
+}
+
+func htons(v uint16) uint16 {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], v)
+	return binary.LittleEndian.Uint16(b[:])
+}
+
+// SendTo wraps b (a DHCPv4 packet) in a UDP/IPv4/Ethernet frame addressed
+// to addr and writes it to the wire. The IPv4 destination is addr.IP
+// (which may be 255.255.255.255); the Ethernet destination is the
+// broadcast address, since the client does not yet know the server's MAC.
+func (r *rawConn) SendTo(addr *net.UDPAddr, b []byte) error {
+	frame, err := buildEthernetIPv4UDP(r.localMAC, broadcastMAC, net.IPv4zero, addr.IP, 68, uint16(addr.Port), b)
+	if err != nil {
+		return err
+	}
+	dst := &unix.SockaddrLinklayer{
+		Protocol: htons(unix.ETH_P_IP),
+		Ifindex:  r.ifIndex,
+		Halen:    6,
+	}
+	copy(dst.Addr[:6], broadcastMAC)
+	return unix.Sendto(r.fd, frame, 0, dst)
+}
+
+// Recv reads raw frames off the wire until it finds one carrying a UDP
+// datagram addressed to port 68 (the DHCP client port) or the deadline
+// passes.
+func (r *rawConn) Recv(timeout time.Duration) ([]byte, error) {
+	deadline := time.Now().Add(timeout)
+	buf := make([]byte, 1600)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		tv := unix.NsecToTimeval(remaining.Nanoseconds())
+		if err := unix.SetsockoptTimeval(r.fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+			return nil, err
+		}
+		n, _, err := unix.Recvfrom(r.fd, buf, 0)
+		if err != nil {
+			return nil, err
+		}
+		payload, dstPort, ok := parseEthernetIPv4UDP(buf[:n])
+		if ok && dstPort == 68 {
+			return payload, nil
+		}
+	}
+	return nil, fmt.Errorf("timed out waiting for a DHCP reply on the raw socket")
+}
+
+func (r *rawConn) Close() error {
+	return unix.Close(r.fd)
+}
+
+// NeedsBroadcast is always true: a rawConn's interface has no IP address
+// configured yet, so a unicast reply would have nowhere to be routed to.
+func (r *rawConn) NeedsBroadcast() bool {
+	return true
+}
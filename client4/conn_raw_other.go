@@ -0,0 +1,16 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+//go:build !linux
+
+package client4
+
+import "fmt"
+
+// NewRawConn is only implemented on Linux, where AF_PACKET sockets are
+// available. On other platforms, run the client against an interface
+// that already has an IP address and use NewUDPConn instead.
+func NewRawConn(iface string) (Conn, error) {
+	return nil, fmt.Errorf("raw-socket mode is not supported on this platform, use a bound IP address instead")
+}
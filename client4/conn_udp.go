@@ -0,0 +1,62 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package client4
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// udpConn is the default Conn implementation: a UDP socket bound to port
+// 68 on the given local address. It requires the local interface to
+// already have an IP address, which is not the case for a client that has
+// not yet completed a DHCP exchange; for that case use NewRawConn
+// instead.
+type udpConn struct {
+	conn *net.UDPConn
+}
+
+// NewUDPConn binds a UDP socket on localAddr:68 suitable for broadcasting
+// DISCOVER/REQUEST messages and receiving the server's replies.
+func NewUDPConn(localAddr net.IP) (Conn, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: localAddr, Port: 68})
+	if err != nil {
+		return nil, fmt.Errorf("binding UDP client socket: %w", err)
+	}
+	if err := conn.SetWriteBuffer(1024 * 1024); err != nil {
+		// Not fatal: larger broadcast domains just become more likely to
+		// drop a packet under load.
+		_ = err
+	}
+	return &udpConn{conn: conn}, nil
+}
+
+func (u *udpConn) SendTo(addr *net.UDPAddr, b []byte) error {
+	_, err := u.conn.WriteToUDP(b, addr)
+	return err
+}
+
+func (u *udpConn) Recv(timeout time.Duration) ([]byte, error) {
+	if err := u.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 1500)
+	n, _, err := u.conn.ReadFromUDP(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func (u *udpConn) Close() error {
+	return u.conn.Close()
+}
+
+// NeedsBroadcast is always false: a udpConn is bound to a real local
+// address, so the server can unicast its reply straight back to it.
+func (u *udpConn) NeedsBroadcast() bool {
+	return false
+}
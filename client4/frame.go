@@ -0,0 +1,110 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package client4
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// broadcastMAC is the Ethernet broadcast address, used as the destination
+// for raw DHCP frames since the client does not yet know the server's
+// hardware address.
+var broadcastMAC = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+const (
+	ethTypeIPv4  = 0x0800
+	ipProtoUDP   = 17
+	ethHeaderLen = 14
+	ipHeaderLen  = 20
+	udpHeaderLen = 8
+)
+
+// buildEthernetIPv4UDP wraps payload in a minimal Ethernet/IPv4/UDP frame.
+// It is used by the raw-socket Conn to send DHCP packets on interfaces
+// with no IP address configured, where the kernel's own UDP stack cannot
+// be used.
+func buildEthernetIPv4UDP(srcMAC, dstMAC net.HardwareAddr, srcIP, dstIP net.IP, srcPort, dstPort uint16, payload []byte) ([]byte, error) {
+	srcIP4, dstIP4 := srcIP.To4(), dstIP.To4()
+	if srcIP4 == nil || dstIP4 == nil {
+		return nil, fmt.Errorf("buildEthernetIPv4UDP requires IPv4 addresses")
+	}
+
+	udpLen := udpHeaderLen + len(payload)
+	udp := make([]byte, udpLen)
+	binary.BigEndian.PutUint16(udp[0:2], srcPort)
+	binary.BigEndian.PutUint16(udp[2:4], dstPort)
+	binary.BigEndian.PutUint16(udp[4:6], uint16(udpLen))
+	copy(udp[8:], payload)
+	// UDP checksum is optional over IPv4; DHCP clients conventionally
+	// leave it unset (0) when bootstrapping without an IP.
+
+	ipLen := ipHeaderLen + udpLen
+	ip := make([]byte, ipHeaderLen)
+	ip[0] = 0x45 // version 4, IHL 5
+	ip[1] = 0    // DSCP/ECN
+	binary.BigEndian.PutUint16(ip[2:4], uint16(ipLen))
+	binary.BigEndian.PutUint16(ip[4:6], 0) // identification
+	binary.BigEndian.PutUint16(ip[6:8], 0) // flags/fragment offset
+	ip[8] = 64                             // TTL
+	ip[9] = ipProtoUDP
+	copy(ip[12:16], srcIP4)
+	copy(ip[16:20], dstIP4)
+	binary.BigEndian.PutUint16(ip[10:12], ipChecksum(ip))
+
+	eth := make([]byte, ethHeaderLen)
+	copy(eth[0:6], dstMAC)
+	copy(eth[6:12], srcMAC)
+	binary.BigEndian.PutUint16(eth[12:14], ethTypeIPv4)
+
+	frame := make([]byte, 0, len(eth)+len(ip)+len(udp))
+	frame = append(frame, eth...)
+	frame = append(frame, ip...)
+	frame = append(frame, udp...)
+	return frame, nil
+}
+
+// parseEthernetIPv4UDP extracts the UDP payload and destination port from
+// a raw Ethernet frame, returning ok=false if it is not an IPv4/UDP frame.
+func parseEthernetIPv4UDP(frame []byte) (payload []byte, dstPort uint16, ok bool) {
+	if len(frame) < ethHeaderLen+ipHeaderLen+udpHeaderLen {
+		return nil, 0, false
+	}
+	if binary.BigEndian.Uint16(frame[12:14]) != ethTypeIPv4 {
+		return nil, 0, false
+	}
+	ip := frame[ethHeaderLen:]
+	ihl := int(ip[0]&0x0f) * 4
+	if ihl < ipHeaderLen || len(ip) < ihl+udpHeaderLen {
+		return nil, 0, false
+	}
+	if ip[9] != ipProtoUDP {
+		return nil, 0, false
+	}
+	udp := ip[ihl:]
+	udpLen := int(binary.BigEndian.Uint16(udp[4:6]))
+	if udpLen < udpHeaderLen || len(udp) < udpLen {
+		return nil, 0, false
+	}
+	dstPort = binary.BigEndian.Uint16(udp[2:4])
+	return udp[udpHeaderLen:udpLen], dstPort, true
+}
+
+// ipChecksum computes the IPv4 header checksum (RFC 791) of hdr, which
+// must have its own checksum field zeroed.
+func ipChecksum(hdr []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(hdr); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(hdr[i : i+2]))
+	}
+	if len(hdr)%2 == 1 {
+		sum += uint32(hdr[len(hdr)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,157 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package client4
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a sequence of Client actions driven from a YAML file, e.g.:
+//
+//	steps:
+//	  - discover
+//	  - expect: offer
+//	  - request
+//	  - expect: ack
+//	  - sleep: 5s
+//	  - renew
+//
+// It lets coredhcp's plugins be exercised as an integration test harness
+// without writing a new Go program per scenario.
+type Scenario struct {
+	Steps []Step `yaml:"steps"`
+}
+
+// Step is one scenario action. It unmarshals from either a bare string
+// ("discover", "request", "release", ...) or a single-key map ("expect:
+// offer", "sleep: 5s", "decline: conflicting address").
+type Step struct {
+	Action string
+	Arg    string
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *Step) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode && value.ShortTag() == "!!str" {
+		s.Action = value.Value
+		return nil
+	}
+	var m map[string]string
+	if err := value.Decode(&m); err != nil {
+		return fmt.Errorf("invalid scenario step %q: %w", value.Value, err)
+	}
+	if len(m) != 1 {
+		return fmt.Errorf("invalid scenario step: want a single-key map, got %d keys", len(m))
+	}
+	for k, v := range m {
+		s.Action, s.Arg = k, v
+	}
+	return nil
+}
+
+// ParseScenario parses a YAML scenario document.
+func ParseScenario(data []byte) (*Scenario, error) {
+	var sc Scenario
+	if err := yaml.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("parsing scenario: %w", err)
+	}
+	return &sc, nil
+}
+
+// Run executes every step against c in order, stopping at the first
+// error.
+func (sc *Scenario) Run(c *Client) error {
+	var lastOffer, last *dhcpv4.DHCPv4
+
+	for i, step := range sc.Steps {
+		switch strings.ToLower(step.Action) {
+		case "discover":
+			offer, err := c.Discover()
+			if err != nil {
+				return fmt.Errorf("step %d (discover): %w", i, err)
+			}
+			lastOffer, last = offer, offer
+
+		case "request":
+			if lastOffer == nil {
+				return fmt.Errorf("step %d (request): no prior offer to request", i)
+			}
+			ack, err := c.Request(lastOffer)
+			last = ack
+			if err != nil {
+				return fmt.Errorf("step %d (request): %w", i, err)
+			}
+
+		case "renew":
+			ack, err := c.Renew()
+			last = ack
+			if err != nil {
+				return fmt.Errorf("step %d (renew): %w", i, err)
+			}
+
+		case "rebind":
+			ack, err := c.Rebind()
+			last = ack
+			if err != nil {
+				return fmt.Errorf("step %d (rebind): %w", i, err)
+			}
+
+		case "release":
+			if err := c.Release(); err != nil {
+				return fmt.Errorf("step %d (release): %w", i, err)
+			}
+
+		case "decline":
+			if lastOffer == nil {
+				return fmt.Errorf("step %d (decline): no prior offer to decline", i)
+			}
+			if err := c.Decline(lastOffer, step.Arg); err != nil {
+				return fmt.Errorf("step %d (decline): %w", i, err)
+			}
+
+		case "sleep":
+			d, err := time.ParseDuration(step.Arg)
+			if err != nil {
+				return fmt.Errorf("step %d (sleep): invalid duration %q: %w", i, step.Arg, err)
+			}
+			time.Sleep(d)
+
+		case "expect":
+			if err := expectMessageType(last, step.Arg); err != nil {
+				return fmt.Errorf("step %d (expect %s): %w", i, step.Arg, err)
+			}
+
+		default:
+			return fmt.Errorf("step %d: unknown action %q", i, step.Action)
+		}
+	}
+	return nil
+}
+
+func expectMessageType(last *dhcpv4.DHCPv4, want string) error {
+	if last == nil {
+		return fmt.Errorf("no message received yet")
+	}
+	var wantType dhcpv4.MessageType
+	switch strings.ToLower(want) {
+	case "offer":
+		wantType = dhcpv4.MessageTypeOffer
+	case "ack":
+		wantType = dhcpv4.MessageTypeAck
+	case "nak":
+		wantType = dhcpv4.MessageTypeNak
+	default:
+		return fmt.Errorf("unknown expected message type %q", want)
+	}
+	if last.MessageType() != wantType {
+		return fmt.Errorf("expected %s, got %s", wantType, last.MessageType())
+	}
+	return nil
+}
@@ -2,26 +2,44 @@
 // This source code is licensed under the MIT license found in the
 // LICENSE file in the root directory of this source tree.
 
-package main
-
 /*
- * Sample DHCPv4 client to test on the local interface
+ * coredhcp-client4 is a DHCPv4 test client for exercising a coredhcp
+ * server (or any RFC 2131 compliant one). With no --script it runs a
+ * plain DISCOVER/REQUEST exchange; --action selects DECLINE/RELEASE/
+ * INFORM/MAINTAIN instead (MAINTAIN keeps the lease alive, auto-renewing
+ * and rebinding at T1/T2 until interrupted); --script runs a YAML
+ * scenario through the client4 state machine, e.g.:
+ *
+ *	steps:
+ *	  - discover
+ *	  - expect: offer
+ *	  - request
+ *	  - expect: ack
+ *	  - sleep: 5s
+ *	  - renew
  */
+package main
 
 import (
 	"flag"
 	"net"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
-	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/toujourser/coredhcp/client4"
 	"github.com/toujourser/coredhcp/logger"
 )
 
 var log = logger.GetLogger("main")
 
 var (
-	serverIP = flag.String("server", "255.255.255.255", "DHCP server IP address")
-	localIP  = flag.String("local", "0.0.0.0", "Local IP address to bind to")
+	localIP = flag.String("local", "0.0.0.0", "Local IP address to bind to (ignored with -iface)")
+	iface   = flag.String("iface", "", "Send/receive over this interface with a raw socket, instead of a bound UDP socket")
+	timeout = flag.Duration("timeout", 5*time.Second, "Timeout per retransmission attempt")
+	action  = flag.String("action", "full", "Action to perform: discover, full (discover+request), decline, release, inform, maintain (discover+request, then auto-renew/rebind at T1/T2 until interrupted)")
+	script  = flag.String("script", "", "Path to a YAML scenario to run instead of -action")
 )
 
 func main() {
@@ -33,94 +51,118 @@ func main() {
 	} else {
 		macString = "fa:16:3e:ac:e6:e5"
 	}
-
 	mac, err := net.ParseMAC(macString)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Create UDP connection
-	conn, err := net.ListenUDP("udp4", &net.UDPAddr{
-		IP:   net.ParseIP(*localIP),
-		Port: 68,
-	})
+	conn, err := newConn()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer conn.Close()
 
-	// Enable broadcast
-	if err := conn.SetWriteBuffer(1024 * 1024); err != nil {
-		log.Printf("Warning: failed to set write buffer: %v", err)
-	}
+	c := client4.New(conn, mac, *timeout)
 
-	serverAddr := &net.UDPAddr{
-		IP:   net.ParseIP(*serverIP),
-		Port: 67,
+	if *script != "" {
+		runScript(c, *script)
+		return
 	}
+	runAction(c, *action)
+}
 
-	log.Printf("Client listening on %s:68", *localIP)
-	log.Printf("Server address: %s:67", *serverIP)
-
-	// Create DHCP Discover message with broadcast flag
-	discover, err := dhcpv4.NewDiscovery(mac,
-		dhcpv4.WithBroadcast(true),
-		dhcpv4.WithRequestedOptions(
-			dhcpv4.OptionSubnetMask,
-			dhcpv4.OptionRouter,
-			dhcpv4.OptionDomainNameServer,
-		))
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	log.Printf("Sending DISCOVER: %s", discover.Summary())
-
-	// Send Discover
-	if _, err := conn.WriteToUDP(discover.ToBytes(), serverAddr); err != nil {
-		log.Fatal(err)
-	}
-
-	// Receive Offer
-	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
-	buffer := make([]byte, 1500)
-	n, _, err := conn.ReadFromUDP(buffer)
-	if err != nil {
-		log.Fatal(err)
+func newConn() (client4.Conn, error) {
+	if *iface != "" {
+		log.Infof("using raw socket on interface %s", *iface)
+		return client4.NewRawConn(*iface)
 	}
+	log.Infof("binding UDP socket on %s:68", *localIP)
+	return client4.NewUDPConn(net.ParseIP(*localIP))
+}
 
-	offer, err := dhcpv4.FromBytes(buffer[:n])
+func runScript(c *client4.Client, path string) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	log.Printf("Received OFFER: %s", offer.Summary())
-
-	// Create DHCP Request message with broadcast flag
-	request, err := dhcpv4.NewRequestFromOffer(offer, dhcpv4.WithBroadcast(true))
+	sc, err := client4.ParseScenario(data)
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	log.Printf("Sending REQUEST: %s", request.Summary())
-
-	// Send Request
-	if _, err := conn.WriteToUDP(request.ToBytes(), serverAddr); err != nil {
-		log.Fatal(err)
-	}
-
-	// Receive Ack
-	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
-	n, _, err = conn.ReadFromUDP(buffer)
-	if err != nil {
+	if err := sc.Run(c); err != nil {
 		log.Fatal(err)
 	}
+	log.Printf("Scenario completed successfully!")
+}
 
-	ack, err := dhcpv4.FromBytes(buffer[:n])
-	if err != nil {
-		log.Fatal(err)
+func runAction(c *client4.Client, action string) {
+	switch action {
+	case "discover":
+		offer, err := c.Discover()
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Received OFFER: %s", offer.Summary())
+
+	case "full":
+		offer, err := c.Discover()
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Received OFFER: %s", offer.Summary())
+
+		ack, err := c.Request(offer)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Received ACK: %s", ack.Summary())
+		log.Printf("DHCP exchange completed successfully!")
+
+	case "decline":
+		offer, err := c.Discover()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := c.Decline(offer, "address already in use"); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Sent DECLINE for %s", offer.YourIPAddr)
+
+	case "release":
+		offer, err := c.Discover()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err := c.Request(offer); err != nil {
+			log.Fatal(err)
+		}
+		if err := c.Release(); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Released lease for %s", offer.YourIPAddr)
+
+	case "inform":
+		ack, err := c.Inform(net.ParseIP(*localIP))
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Received ACK: %s", ack.Summary())
+
+	case "maintain":
+		stop := make(chan struct{})
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sig
+			close(stop)
+		}()
+		log.Printf("maintaining lease until interrupted (Ctrl-C)...")
+		if err := c.Maintain(stop); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("maintain stopped")
+
+	default:
+		log.Fatal("unknown -action, want one of: discover, full, decline, release, inform, maintain")
 	}
-
-	log.Printf("Received ACK: %s", ack.Summary())
-	log.Printf("DHCP exchange completed successfully!")
 }
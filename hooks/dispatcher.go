@@ -0,0 +1,78 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package hooks
+
+import (
+	"sync"
+	"time"
+
+	"github.com/toujourser/coredhcp/logger"
+)
+
+var log = logger.GetLogger("hooks")
+
+// Dispatcher fans events out to every configured Sink from a fixed pool of
+// worker goroutines, decoupling hook I/O latency from the DHCP handler
+// goroutine that emits the event.
+type Dispatcher struct {
+	ring    *ring
+	sinks   []Sink
+	workers int
+	wg      sync.WaitGroup
+}
+
+// NewDispatcher creates a Dispatcher with the given ring buffer capacity
+// and worker pool size, delivering events to every sink. Call Start to
+// begin processing and Close to drain and stop it.
+func NewDispatcher(bufferSize, workers int, sinks ...Sink) *Dispatcher {
+	if bufferSize <= 0 {
+		bufferSize = 1024
+	}
+	if workers <= 0 {
+		workers = 2
+	}
+	return &Dispatcher{ring: newRing(bufferSize), sinks: sinks, workers: workers}
+}
+
+// Start launches the worker pool. It must be called once before any
+// events are expected to be delivered.
+func (d *Dispatcher) Start() {
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go d.run()
+	}
+}
+
+func (d *Dispatcher) run() {
+	defer d.wg.Done()
+	for {
+		e, ok := d.ring.pop()
+		if !ok {
+			return
+		}
+		for _, s := range d.sinks {
+			if err := s.Emit(e); err != nil {
+				log.Warnf("hook sink %s failed to emit %s event for %s: %v", s.Name(), e.Type, e.MAC, err)
+			}
+		}
+	}
+}
+
+// Emit queues e for asynchronous delivery to every sink. It never blocks;
+// if the ring buffer is full the oldest queued event is dropped in favor
+// of e.
+func (d *Dispatcher) Emit(e Event) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	d.ring.push(e)
+}
+
+// Close stops accepting new work and waits for every worker to drain the
+// buffer and exit.
+func (d *Dispatcher) Close() {
+	d.ring.close()
+	d.wg.Wait()
+}
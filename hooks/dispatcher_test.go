@@ -0,0 +1,65 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package hooks
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recordingSink) Name() string { return "recording" }
+
+func (r *recordingSink) Emit(e Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *recordingSink) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events)
+}
+
+func TestDispatcherDeliversToSink(t *testing.T) {
+	sink := &recordingSink{}
+	d := NewDispatcher(16, 1, sink)
+	d.Start()
+	defer d.Close()
+
+	d.Emit(Event{Type: Drop, MAC: "00:11:22:33:44:55"})
+
+	require.Eventually(t, func() bool { return sink.count() == 1 }, time.Second, time.Millisecond)
+}
+
+func TestRingOverwritesOldestWhenFull(t *testing.T) {
+	r := newRing(2)
+	r.push(Event{MAC: "1"})
+	r.push(Event{MAC: "2"})
+	r.push(Event{MAC: "3"}) // overwrites "1"
+
+	e, ok := r.pop()
+	require.True(t, ok)
+	assert.Equal(t, "2", e.MAC)
+
+	e, ok = r.pop()
+	require.True(t, ok)
+	assert.Equal(t, "3", e.MAC)
+}
+
+func TestEmitIsNoopWithoutDispatcher(t *testing.T) {
+	SetDispatcher(nil)
+	assert.NotPanics(t, func() { Emit(Event{Type: Drop, MAC: "00:11:22:33:44:55"}) })
+}
@@ -0,0 +1,73 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package hooks implements a cross-cutting lease/event notification
+// subsystem. Plugins emit lifecycle events (a client was offered a lease,
+// denied, handed a boot file, ...) through the package-level Emit
+// function; a Dispatcher fans each event out, from a small pool of worker
+// goroutines, to every configured Sink (webhook, syslog, file, ...), so
+// that hook I/O never blocks the DHCP handler goroutine that produced the
+// event.
+package hooks
+
+import "time"
+
+// EventType identifies a point in a client's DHCP lifecycle.
+type EventType string
+
+const (
+	// Discover is emitted when a DHCPv4 DISCOVER (or DHCPv6 SOLICIT) is
+	// received.
+	Discover EventType = "discover"
+	// Offer is emitted when a lease or boot information is offered to a
+	// client.
+	Offer EventType = "offer"
+	// Request is emitted when a DHCPv4 REQUEST (or DHCPv6 REQUEST) is
+	// received.
+	Request EventType = "request"
+	// Ack is emitted when a lease is acknowledged.
+	Ack EventType = "ack"
+	// Nak is emitted when a request is negatively acknowledged.
+	Nak EventType = "nak"
+	// Decline is emitted when a client declines an offered address.
+	Decline EventType = "decline"
+	// Release is emitted when a client releases its lease.
+	Release EventType = "release"
+	// Drop is emitted when a plugin drops a request outright, e.g. a
+	// whitelist/blacklist denial.
+	Drop EventType = "drop"
+)
+
+// Event describes a single lifecycle occurrence for one DHCP transaction.
+type Event struct {
+	Type      EventType `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	// MAC is the client hardware address, in its usual colon-separated
+	// form.
+	MAC string `json:"mac"`
+	// XID is the DHCP transaction ID, if known.
+	XID string `json:"xid,omitempty"`
+	// OfferedIP is the lease address involved, if any.
+	OfferedIP string `json:"offered_ip,omitempty"`
+	// RequestedOptions lists the DHCP option codes the client requested.
+	RequestedOptions []int `json:"requested_options,omitempty"`
+	// Plugins lists the plugin chain that produced this event, in
+	// execution order.
+	Plugins []string `json:"plugins,omitempty"`
+	// Reason is a short, human-readable explanation of the decision,
+	// e.g. "denied by deny ACL" or "nbp assigned http://.../boot.ipxe".
+	Reason string `json:"reason,omitempty"`
+}
+
+// Sink receives events fanned out by a Dispatcher. Implementations must be
+// safe for concurrent use, since a Dispatcher may run several worker
+// goroutines.
+type Sink interface {
+	// Name identifies the sink in logs, e.g. "webhook".
+	Name() string
+	// Emit delivers a single event. It may block; the Dispatcher calls it
+	// from a dedicated worker goroutine so a slow sink never blocks the
+	// DHCP handler that produced the event.
+	Emit(Event) error
+}
@@ -0,0 +1,35 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package hooks
+
+import "sync"
+
+var (
+	mu      sync.RWMutex
+	current *Dispatcher
+)
+
+// SetDispatcher installs d as the process-wide dispatcher used by Emit. It
+// is normally called once at startup, after the sinks declared in the
+// `hooks` config section have been built. Passing nil makes Emit a no-op,
+// which is also the default before SetDispatcher is ever called.
+func SetDispatcher(d *Dispatcher) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = d
+}
+
+// Emit hands e to the process-wide dispatcher, if one has been installed.
+// It is safe to call from any plugin handler; delivery to sinks happens
+// asynchronously and never blocks the caller.
+func Emit(e Event) {
+	mu.RLock()
+	d := current
+	mu.RUnlock()
+	if d == nil {
+		return
+	}
+	d.Emit(e)
+}
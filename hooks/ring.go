@@ -0,0 +1,65 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package hooks
+
+import "sync"
+
+// ring is a fixed-capacity circular buffer of events. When full, push
+// overwrites the oldest unread event rather than blocking the caller, so
+// a DHCP handler goroutine emitting an event never waits on a slow
+// consumer.
+type ring struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	buf    []Event
+	head   int // next write position
+	size   int // number of valid, unread entries
+	closed bool
+}
+
+func newRing(capacity int) *ring {
+	r := &ring{buf: make([]Event, capacity)}
+	r.cond = sync.NewCond(&r.mu)
+	return r
+}
+
+// push adds e to the buffer, overwriting the oldest entry if the buffer
+// is already full.
+func (r *ring) push(e Event) {
+	r.mu.Lock()
+	r.buf[r.head] = e
+	r.head = (r.head + 1) % len(r.buf)
+	if r.size < len(r.buf) {
+		r.size++
+	}
+	r.mu.Unlock()
+	r.cond.Signal()
+}
+
+// pop removes and returns the oldest buffered event, blocking until one is
+// available or the ring is closed (in which case ok is false).
+func (r *ring) pop() (e Event, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for r.size == 0 && !r.closed {
+		r.cond.Wait()
+	}
+	if r.size == 0 {
+		return Event{}, false
+	}
+	idx := (r.head - r.size + len(r.buf)) % len(r.buf)
+	e = r.buf[idx]
+	r.size--
+	return e, true
+}
+
+// close unblocks every goroutine waiting in pop, draining the buffer
+// first.
+func (r *ring) close() {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	r.cond.Broadcast()
+}
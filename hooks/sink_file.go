@@ -0,0 +1,43 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// FileSink appends each event as a single line of JSON (JSONL) to a file.
+type FileSink struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// NewFileSink opens (creating if necessary) path for appending and
+// returns a sink that writes one JSON object per event.
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Name implements Sink.
+func (s *FileSink) Name() string { return "file" }
+
+// Emit implements Sink.
+func (s *FileSink) Emit(e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(e)
+}
+
+// Close closes the underlying file.
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
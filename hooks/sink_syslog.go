@@ -0,0 +1,70 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package hooks
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// syslog facility/severity used for every event: user-level (1) messages
+// at the informational (6) severity, per RFC 5424 section 6.2.1.
+const syslogPriority = 1<<3 | 6
+
+// SyslogSink writes each event as an RFC 5424 syslog message over a
+// network connection (typically UDP, as is conventional for syslog).
+type SyslogSink struct {
+	Hostname string
+	AppName  string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogSink dials network/addr (e.g. "udp", "syslog.example.com:514")
+// and returns a sink that writes RFC 5424 formatted messages to it.
+func NewSyslogSink(network, addr string) (*SyslogSink, error) {
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("dialing syslog at %s://%s: %w", network, addr, err)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+	return &SyslogSink{Hostname: hostname, AppName: "coredhcp", conn: conn}, nil
+}
+
+// Name implements Sink.
+func (s *SyslogSink) Name() string { return "syslog" }
+
+// Emit implements Sink.
+func (s *SyslogSink) Emit(e Event) error {
+	msg := formatRFC5424(s.Hostname, s.AppName, e)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.conn.Write([]byte(msg))
+	return err
+}
+
+// Close closes the underlying connection.
+func (s *SyslogSink) Close() error {
+	return s.conn.Close()
+}
+
+// formatRFC5424 renders e as a single RFC 5424 syslog message, with the
+// event's fields carried as structured data under the "coredhcp@32473"
+// SD-ID.
+func formatRFC5424(hostname, appName string, e Event) string {
+	structured := fmt.Sprintf(
+		`[coredhcp@32473 mac="%s" xid="%s" type="%s" offeredIP="%s"]`,
+		e.MAC, e.XID, e.Type, e.OfferedIP,
+	)
+	return fmt.Sprintf("<%d>1 %s %s %s - - %s %s\n",
+		syslogPriority, e.Timestamp.UTC().Format(time.RFC3339), hostname, appName, structured, e.Reason)
+}
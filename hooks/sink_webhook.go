@@ -0,0 +1,64 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookSink POSTs each event as JSON to a configured URL, retrying with
+// exponential backoff on failure or a non-2xx response.
+type WebhookSink struct {
+	URL        string
+	Client     *http.Client
+	MaxRetries int
+	BaseDelay  time.Duration
+}
+
+// NewWebhookSink returns a WebhookSink with sensible retry/backoff
+// defaults: 3 retries, starting at 200ms and doubling each attempt.
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		URL:        url,
+		Client:     &http.Client{Timeout: 5 * time.Second},
+		MaxRetries: 3,
+		BaseDelay:  200 * time.Millisecond,
+	}
+}
+
+// Name implements Sink.
+func (w *WebhookSink) Name() string { return "webhook" }
+
+// Emit implements Sink.
+func (w *WebhookSink) Emit(e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshaling event: %w", err)
+	}
+
+	var lastErr error
+	delay := w.BaseDelay
+	for attempt := 0; attempt <= w.MaxRetries; attempt++ {
+		resp, err := w.Client.Post(w.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			lastErr = err
+		} else {
+			resp.Body.Close()
+			if resp.StatusCode < 300 {
+				return nil
+			}
+			lastErr = fmt.Errorf("webhook %s returned status %d", w.URL, resp.StatusCode)
+		}
+		if attempt < w.MaxRetries {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return lastErr
+}
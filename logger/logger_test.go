@@ -0,0 +1,44 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package logger
+
+import (
+	"net"
+	"testing"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLoggerIsCached(t *testing.T) {
+	a := GetLogger("plugins/test")
+	b := GetLogger("plugins/test")
+	assert.Same(t, a, b)
+}
+
+func TestConfigureInvalidLevel(t *testing.T) {
+	err := Configure(Config{Level: "not-a-level"})
+	assert.Error(t, err)
+}
+
+func TestConfigureValidLevel(t *testing.T) {
+	err := Configure(Config{Format: "json", Level: "debug"})
+	assert.NoError(t, err)
+	// restore the default for other tests in this package
+	require.NoError(t, Configure(Config{}))
+}
+
+func TestWithRequest4(t *testing.T) {
+	mac, err := net.ParseMAC("00:11:22:33:44:55")
+	require.NoError(t, err)
+	req, err := dhcpv4.NewDiscovery(mac)
+	require.NoError(t, err)
+
+	l := GetLogger("plugins/test").WithRequest4(req)
+	require.NotNil(t, l)
+	// Should not panic when used.
+	l.Debugf("test message")
+}
@@ -37,6 +37,7 @@ import (
 	"github.com/insomniacslk/dhcp/dhcpv6"
 	"github.com/insomniacslk/dhcp/iana"
 	"github.com/toujourser/coredhcp/handler"
+	"github.com/toujourser/coredhcp/hooks"
 	"github.com/toujourser/coredhcp/logger"
 	"github.com/toujourser/coredhcp/plugins"
 )
@@ -107,6 +108,8 @@ func setup4(args ...string) (handler.Handler4, error) {
 	}
 
 	return func(req, resp *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, bool) {
+		rlog := log.WithRequest4(req)
+
 		// 获取客户端架构
 		archs := req.ClientArch()
 		archStr := getArchString(archs)
@@ -150,21 +153,28 @@ func setup4(args ...string) (handler.Handler4, error) {
 			resp.Options.Update(*bootOpt)
 		}
 
-		log.Printf("u.Scheme: %s, u.Host: %s, u.Path: %s, Generated boot path for [%s]: [%s]", u.Scheme, u.Host, u.Path, archStr, modifiedURL.String())
-		log.Print("----------------------------------------------------")
+		rlog.Debugf("u.Scheme: %s, u.Host: %s, u.Path: %s, Generated boot path for [%s]: [%s]", u.Scheme, u.Host, u.Path, archStr, modifiedURL.String())
+		hooks.Emit(hooks.Event{
+			Type:    hooks.Offer,
+			MAC:     req.ClientHWAddr.String(),
+			XID:     req.TransactionID.String(),
+			Plugins: []string{"nbp"},
+			Reason:  fmt.Sprintf("nbp assigned %s for arch %s", modifiedURL.String(), archStr),
+		})
 		return resp, true
 	}, nil
 
 }
 
 func nbpHandler6(req, resp dhcpv6.DHCPv6) (dhcpv6.DHCPv6, bool) {
+	rlog := log.WithRequest6(req)
 	if opt59 == nil {
 		// nothing to do
 		return resp, true
 	}
 	decap, err := req.GetInnerMessage()
 	if err != nil {
-		log.Errorf("Could not decapsulate request: %v", err)
+		rlog.Errorf("Could not decapsulate request: %v", err)
 		// drop the request, this is probably a critical error in the packet.
 		return nil, true
 	}
@@ -179,23 +189,41 @@ func nbpHandler6(req, resp dhcpv6.DHCPv6) (dhcpv6.DHCPv6, bool) {
 			}
 		}
 	}
-	log.Debugf("Added NBP %s to request", opt59)
+	rlog.Debugf("Added NBP %s to request", opt59)
+	var mac string
+	if m, err := dhcpv6.ExtractMAC(req); err == nil {
+		mac = m.String()
+	}
+	hooks.Emit(hooks.Event{
+		Type:    hooks.Offer,
+		MAC:     mac,
+		Plugins: []string{"nbp"},
+		Reason:  fmt.Sprintf("nbp assigned %s", opt59),
+	})
 	return resp, true
 }
 
 func nbpHandler4(req, resp *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, bool) {
+	rlog := log.WithRequest4(req)
 	if opt67 == nil {
 		// nothing to do
 		return resp, true
 	}
 	if req.IsOptionRequested(dhcpv4.OptionTFTPServerName) && opt66 != nil {
 		resp.Options.Update(*opt66)
-		log.Debugf("Added NBP %s / %s to request", opt66, opt67)
+		rlog.Debugf("Added NBP %s / %s to request", opt66, opt67)
 	}
 	if req.IsOptionRequested(dhcpv4.OptionBootfileName) {
 		resp.Options.Update(*opt67)
-		log.Debugf("Added NBP %s to request", opt67)
+		rlog.Debugf("Added NBP %s to request", opt67)
 	}
+	hooks.Emit(hooks.Event{
+		Type:    hooks.Offer,
+		MAC:     req.ClientHWAddr.String(),
+		XID:     req.TransactionID.String(),
+		Plugins: []string{"nbp"},
+		Reason:  fmt.Sprintf("nbp assigned %s", opt67),
+	})
 	return resp, true
 }
 
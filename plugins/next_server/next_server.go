@@ -6,6 +6,7 @@ import (
 
 	"github.com/insomniacslk/dhcp/dhcpv4"
 	"github.com/toujourser/coredhcp/handler"
+	"github.com/toujourser/coredhcp/hooks"
 	"github.com/toujourser/coredhcp/logger"
 	"github.com/toujourser/coredhcp/plugins"
 )
@@ -48,6 +49,13 @@ func (p NextServerPlugin) handle4(req, resp *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, boo
 	}
 	// Set the next-server (siaddr) field in the DHCPv4 response
 	resp.ServerIPAddr = p.NextServer.To4()
-	log.Debugf("Set next-server %s in DHCP response for client %s", p.NextServer.String(), req.ClientHWAddr.String())
+	log.WithRequest4(req).Debugf("Set next-server %s in DHCP response", p.NextServer.String())
+	hooks.Emit(hooks.Event{
+		Type:    hooks.Offer,
+		MAC:     req.ClientHWAddr.String(),
+		XID:     req.TransactionID.String(),
+		Plugins: []string{PluginName},
+		Reason:  fmt.Sprintf("next-server set to %s", p.NextServer),
+	})
 	return resp, false // Continue to next plugin
 }
@@ -0,0 +1,265 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package options implements a generic, config-driven DHCP option
+// injection plugin. It lets operators set (or strip) arbitrary options
+// without writing a dedicated plugin for each one, by declaring a spec of
+// the form:
+//
+//	<code> <type> <value>
+//	del <code>
+//
+// where <type> is one of:
+//
+//	ip               a single dotted-quad/IPv6 address, e.g. "192.0.2.1"
+//	ips              a comma-separated list of addresses, e.g. "8.8.8.8,1.1.1.1"
+//	text             an opaque string, e.g. "example.com"
+//	hex              raw option data as a hex string, e.g. "DEADBEEF"
+//	bool             "true" or "false", encoded as a single byte
+//	u8, u16, u32     an unsigned integer of the given width
+//	classless-route  one or more comma-separated RFC 3442 routes, each
+//	                 "<network>/<prefix>:<gateway>", e.g.
+//	                 "10.0.0.0/8:192.168.1.1,0.0.0.0/0:192.168.1.254"
+//
+// A plugin entry accepts a list of such specs written one after another,
+// each token of a spec being its own plugin argument (three for
+// "<code> <type> <value>", two for "del <code>"), and applies them to the
+// response in order. The `del <code>` form removes an
+// option that an earlier plugin in the chain (e.g. a server default) may
+// already have set on the response.
+//
+// Example usage, setting four options (and removing a fifth) in a single
+// declaration:
+//
+// server4:
+//   - plugins:
+//   - options: 6 ips 8.8.8.8,1.1.1.1 15 text example.com 43 hex DEADBEEF 121 classless-route 10.0.0.0/8:192.168.1.1 del 252
+package options
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv6"
+	"github.com/toujourser/coredhcp/handler"
+	"github.com/toujourser/coredhcp/logger"
+	"github.com/toujourser/coredhcp/plugins"
+)
+
+var log = logger.GetLogger("plugins/options")
+
+// Plugin wraps plugin registration information
+var Plugin = plugins.Plugin{
+	Name:   "options",
+	Setup6: setup6,
+	Setup4: setup4,
+}
+
+// spec is a parsed "<code> <type> <value>" or "del <code>" directive.
+type spec struct {
+	code   int
+	delete bool
+	data   []byte
+}
+
+// parseSpecs consumes args into a list of specs: each "del <code>" takes
+// two tokens, each "<code> <type> <value>" takes three, one after another
+// until args is exhausted.
+func parseSpecs(args ...string) ([]*spec, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("options plugin expects at least one \"<code> <type> <value>\" or \"del <code>\" spec")
+	}
+	var specs []*spec
+	for len(args) > 0 {
+		if strings.EqualFold(args[0], "del") {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("options plugin expects \"del <code>\", got %q", args)
+			}
+			code, err := strconv.Atoi(args[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid option code %q: %w", args[1], err)
+			}
+			specs = append(specs, &spec{code: code, delete: true})
+			args = args[2:]
+			continue
+		}
+		if len(args) < 3 {
+			return nil, fmt.Errorf("options plugin expects \"<code> <type> <value>\" or \"del <code>\", got %q", args)
+		}
+		code, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid option code %q: %w", args[0], err)
+		}
+		data, err := encodeValue(args[1], args[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for option %d: %w", code, err)
+		}
+		specs = append(specs, &spec{code: code, data: data})
+		args = args[3:]
+	}
+	return specs, nil
+}
+
+func encodeValue(typ, value string) ([]byte, error) {
+	switch typ {
+	case "ip":
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", value)
+		}
+		if v4 := ip.To4(); v4 != nil {
+			return v4, nil
+		}
+		return ip.To16(), nil
+	case "ips":
+		var out []byte
+		for _, s := range strings.Split(value, ",") {
+			ip := net.ParseIP(strings.TrimSpace(s))
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", s)
+			}
+			if v4 := ip.To4(); v4 != nil {
+				out = append(out, v4...)
+			} else {
+				out = append(out, ip.To16()...)
+			}
+		}
+		return out, nil
+	case "text":
+		return []byte(value), nil
+	case "hex":
+		data, err := hex.DecodeString(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex string %q: %w", value, err)
+		}
+		return data, nil
+	case "bool":
+		switch strings.ToLower(value) {
+		case "true":
+			return []byte{1}, nil
+		case "false":
+			return []byte{0}, nil
+		default:
+			return nil, fmt.Errorf("invalid bool %q, want true or false", value)
+		}
+	case "u8":
+		n, err := strconv.ParseUint(value, 10, 8)
+		if err != nil {
+			return nil, err
+		}
+		return []byte{byte(n)}, nil
+	case "u16":
+		n, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 2)
+		binary.BigEndian.PutUint16(buf, uint16(n))
+		return buf, nil
+	case "u32":
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 4)
+		binary.BigEndian.PutUint32(buf, uint32(n))
+		return buf, nil
+	case "classless-route":
+		return encodeClasslessRoutes(value)
+	default:
+		return nil, fmt.Errorf("unknown option type %q", typ)
+	}
+}
+
+// encodeClasslessRoutes encodes one or more comma-separated
+// "<network>/<prefix>:<gateway>" routes per RFC 3442: each route is a
+// descriptor byte holding the prefix length, followed by the significant
+// octets of the network address, followed by the 4-byte gateway.
+func encodeClasslessRoutes(value string) ([]byte, error) {
+	var out []byte
+	for _, route := range strings.Split(value, ",") {
+		route = strings.TrimSpace(route)
+		parts := strings.SplitN(route, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid classless route %q, want \"network/prefix:gateway\"", route)
+		}
+		_, network, err := net.ParseCIDR(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", parts[0], err)
+		}
+		gateway := net.ParseIP(parts[1]).To4()
+		if gateway == nil {
+			return nil, fmt.Errorf("invalid gateway %q", parts[1])
+		}
+		v4 := network.IP.To4()
+		if v4 == nil {
+			return nil, fmt.Errorf("invalid network %q: classless-route only supports IPv4", parts[0])
+		}
+		ones, _ := network.Mask.Size()
+		significantOctets := (ones + 7) / 8
+		out = append(out, byte(ones))
+		out = append(out, v4[:significantOctets]...)
+		out = append(out, gateway...)
+	}
+	return out, nil
+}
+
+func setup4(args ...string) (handler.Handler4, error) {
+	specs, err := parseSpecs(args...)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("loaded options plugin for DHCPv4 with %d specs", len(specs))
+	return func(req, resp *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, bool) {
+		for _, s := range specs {
+			resp, _ = s.handle4(req, resp)
+		}
+		return resp, false
+	}, nil
+}
+
+func setup6(args ...string) (handler.Handler6, error) {
+	specs, err := parseSpecs(args...)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("loaded options plugin for DHCPv6 with %d specs", len(specs))
+	return func(req, resp dhcpv6.DHCPv6) (dhcpv6.DHCPv6, bool) {
+		for _, s := range specs {
+			resp, _ = s.handle6(req, resp)
+		}
+		return resp, false
+	}, nil
+}
+
+func (s *spec) handle4(req, resp *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, bool) {
+	code := dhcpv4.GenericOptionCode(s.code)
+	if s.delete {
+		resp.Options.Del(code)
+		log.Debugf("deleted option %d from response for %s", s.code, req.ClientHWAddr)
+		return resp, false
+	}
+	resp.Options.Update(dhcpv4.Option{Code: code, Value: dhcpv4.OptionGeneric{Data: s.data}})
+	log.Debugf("set option %d on response for %s", s.code, req.ClientHWAddr)
+	return resp, false
+}
+
+func (s *spec) handle6(req, resp dhcpv6.DHCPv6) (dhcpv6.DHCPv6, bool) {
+	code := dhcpv6.OptionCode(s.code)
+	if s.delete {
+		if msg, ok := resp.(*dhcpv6.Message); ok {
+			msg.Options = msg.Options.Del(code)
+			log.Debugf("deleted option %d from response", s.code)
+		}
+		return resp, false
+	}
+	resp.AddOption(&dhcpv6.OptionGeneric{OptionCode: code, OptionData: s.data})
+	log.Debugf("set option %d on response", s.code)
+	return resp, false
+}
@@ -0,0 +1,93 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package options
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeValue(t *testing.T) {
+	cases := []struct {
+		typ, value string
+		want       []byte
+	}{
+		{"ip", "192.0.2.1", []byte{192, 0, 2, 1}},
+		{"ips", "8.8.8.8,1.1.1.1", []byte{8, 8, 8, 8, 1, 1, 1, 1}},
+		{"text", "example.com", []byte("example.com")},
+		{"hex", "DEADBEEF", []byte{0xDE, 0xAD, 0xBE, 0xEF}},
+		{"bool", "true", []byte{1}},
+		{"bool", "false", []byte{0}},
+		{"u8", "42", []byte{42}},
+		{"u16", "256", []byte{1, 0}},
+		{"u32", "65536", []byte{0, 1, 0, 0}},
+	}
+	for _, tc := range cases {
+		got, err := encodeValue(tc.typ, tc.value)
+		require.NoError(t, err, tc.typ)
+		assert.Equal(t, tc.want, got, tc.typ)
+	}
+}
+
+func TestEncodeValueErrors(t *testing.T) {
+	_, err := encodeValue("ip", "not-an-ip")
+	assert.Error(t, err)
+
+	_, err = encodeValue("bool", "maybe")
+	assert.Error(t, err)
+
+	_, err = encodeValue("unknown-type", "x")
+	assert.Error(t, err)
+}
+
+func TestEncodeClasslessRoutes(t *testing.T) {
+	got, err := encodeClasslessRoutes("10.0.0.0/8:192.168.1.1")
+	require.NoError(t, err)
+	// descriptor byte (prefix length) + 1 significant octet + 4-byte gateway
+	assert.Equal(t, []byte{8, 10, 192, 168, 1, 1}, got)
+}
+
+func TestParseSpecs(t *testing.T) {
+	specs, err := parseSpecs("6", "ips", "8.8.8.8,1.1.1.1", "del", "43")
+	require.NoError(t, err)
+	require.Len(t, specs, 2)
+	assert.Equal(t, 6, specs[0].code)
+	assert.False(t, specs[0].delete)
+	assert.Equal(t, []byte{8, 8, 8, 8, 1, 1, 1, 1}, specs[0].data)
+	assert.Equal(t, 43, specs[1].code)
+	assert.True(t, specs[1].delete)
+}
+
+func TestParseSpecsMultiple(t *testing.T) {
+	specs, err := parseSpecs(
+		"6", "ips", "8.8.8.8,1.1.1.1",
+		"15", "text", "example.com",
+		"43", "hex", "DEADBEEF",
+		"121", "classless-route", "10.0.0.0/8:192.168.1.1",
+	)
+	require.NoError(t, err)
+	require.Len(t, specs, 4)
+	assert.Equal(t, 15, specs[1].code)
+	assert.Equal(t, []byte("example.com"), specs[1].data)
+	assert.Equal(t, 121, specs[3].code)
+}
+
+func TestParseSpecsInvalid(t *testing.T) {
+	_, err := parseSpecs("6", "ips")
+	assert.Error(t, err)
+
+	_, err = parseSpecs("notanumber", "text", "x")
+	assert.Error(t, err)
+
+	_, err = parseSpecs()
+	assert.Error(t, err)
+}
+
+func TestEncodeClasslessRoutesRejectsIPv6Network(t *testing.T) {
+	_, err := encodeClasslessRoutes("2001:db8::/32:192.168.1.1")
+	assert.Error(t, err)
+}
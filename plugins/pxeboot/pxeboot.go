@@ -0,0 +1,286 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package pxeboot implements a chain-boot aware NBP plugin for PXE/iPXE
+// clients. Unlike the `nbp` plugin, which always serves the same NBP URL to
+// every client, pxeboot inspects DHCPv4 option 93 (Client System
+// Architecture), DHCPv6 OPT_CLIENT_ARCH_TYPE (option 61), and DHCPv4 option
+// 77 / DHCPv6 User Class to tell apart the two PXE boot stages:
+//
+//   - stage 1, the client boots straight from firmware (no User Class, or a
+//     User Class other than "iPXE") and should receive a small NBP such as
+//     undionly.kpxe (BIOS) or ipxe.efi / snponly-arm64.efi (UEFI);
+//   - stage 2, the client has chain-loaded into iPXE and re-requests with
+//     User Class "iPXE", and should receive the HTTP(S) URL of an iPXE
+//     script.
+//
+// The mapping from (architecture, user class) to boot URL is configured as
+// a list of "arch,userclass,url" triples, one per plugin argument. The arch
+// field is one of "x86" (EFI_X86_64), "arm64" (EFI_ARM64), "x86legacy"
+// (INTEL_X86PC) or "default" (matches any architecture not otherwise
+// listed); the userclass field may be left empty to match any/no user
+// class. Entries are matched in this order: (arch, userclass), then
+// ("default", userclass), then (arch, ""), then ("default", ""), so a
+// user-class entry such as "default,iPXE,..." takes precedence over an
+// arch-only entry.
+//
+// For DHCPv4, when the resolved URL has an "http", "https" or "ftp" scheme
+// it is placed in the Bootfile Name option (option 67); otherwise it is
+// treated as a TFTP URL: its host populates the TFTP Server Name option
+// (option 66) and, when it is an IPv4 address, the Next Server field
+// (siaddr), and its path populates the Bootfile Name. For DHCPv6 the full
+// URL, whatever its scheme, is placed in OPT_BOOTFILE_URL (option 59).
+//
+// Example usage:
+//
+// server4:
+//   - plugins:
+//   - pxeboot: "x86legacy,,tftp://10.0.0.1/undionly.kpxe" "x86,,tftp://10.0.0.1/ipxe.efi" "arm64,,tftp://10.0.0.1/snponly-arm64.efi" "default,iPXE,http://10.0.0.1/boot.ipxe"
+//
+// server6:
+//   - plugins:
+//   - pxeboot: "default,,tftp://[2001:db8::1]/ipxe.efi" "default,iPXE,http://[2001:db8::1]/boot.ipxe"
+package pxeboot
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv6"
+	"github.com/insomniacslk/dhcp/iana"
+	"github.com/toujourser/coredhcp/handler"
+	"github.com/toujourser/coredhcp/logger"
+	"github.com/toujourser/coredhcp/plugins"
+)
+
+var log = logger.GetLogger("plugins/pxeboot")
+
+// Plugin wraps plugin registration information
+var Plugin = plugins.Plugin{
+	Name:   "pxeboot",
+	Setup6: setup6,
+	Setup4: setup4,
+}
+
+// bootKey identifies a (architecture, user class) combination.
+type bootKey struct {
+	arch      string
+	userClass string
+}
+
+// bootMap holds the configured arch/userclass -> URL entries for one
+// protocol. setup4 and setup6 each parse their own args into a separate
+// bootMap and close over it, since a `server4:` config and a `server6:`
+// config may list entirely different entries.
+type bootMap map[bootKey]*url.URL
+
+const archDefault = "default"
+
+// parseArgs parses the "arch,userclass,url" triples passed as plugin
+// arguments into a bootMap.
+func parseArgs(args ...string) (bootMap, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("at least one arch,userclass,url entry must be passed to the pxeboot plugin")
+	}
+	m := make(bootMap, len(args))
+	for _, arg := range args {
+		parts := strings.SplitN(arg, ",", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid pxeboot entry %q, expected \"arch,userclass,url\"", arg)
+		}
+		arch := strings.TrimSpace(parts[0])
+		userClass := strings.TrimSpace(parts[1])
+		u, err := url.Parse(strings.TrimSpace(parts[2]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL in pxeboot entry %q: %w", arg, err)
+		}
+		if arch == "" {
+			arch = archDefault
+		}
+		m[bootKey{arch: arch, userClass: userClass}] = u
+	}
+	return m, nil
+}
+
+func setup6(args ...string) (handler.Handler6, error) {
+	m, err := parseArgs(args...)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("loaded pxeboot plugin for DHCPv6 with %d entries.", len(m))
+	return func(req, resp dhcpv6.DHCPv6) (dhcpv6.DHCPv6, bool) {
+		return pxeHandler6(m, req, resp)
+	}, nil
+}
+
+func setup4(args ...string) (handler.Handler4, error) {
+	m, err := parseArgs(args...)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("loaded pxeboot plugin for DHCPv4 with %d entries.", len(m))
+	return func(req, resp *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, bool) {
+		return pxeHandler4(m, req, resp)
+	}, nil
+}
+
+// archString maps a client architecture type, as advertised in DHCPv4
+// option 93 / DHCPv6 option 61, to the short arch identifiers used in the
+// plugin configuration.
+func archString(arch iana.Arch) string {
+	switch arch {
+	case iana.EFI_X86_64:
+		return "x86"
+	case iana.EFI_ARM64:
+		return "arm64"
+	case iana.INTEL_X86PC:
+		return "x86legacy"
+	default:
+		return archDefault
+	}
+}
+
+// userClasses4 extracts the list of user class strings advertised in
+// DHCPv4 option 77 (RFC 3004: each entry is a length-prefixed string).
+func userClasses4(req *dhcpv4.DHCPv4) []string {
+	data := req.Options.Get(dhcpv4.OptionUserClassInformation)
+	if data == nil {
+		return nil
+	}
+	var classes []string
+	for len(data) > 0 {
+		n := int(data[0])
+		data = data[1:]
+		if n == 0 || n > len(data) {
+			break
+		}
+		classes = append(classes, string(data[:n]))
+		data = data[n:]
+	}
+	return classes
+}
+
+// resolve picks the configured URL for the given arch and user classes:
+// an (arch, userclass) match first, then a ("default", userclass) match,
+// then an arch-only match and finally a default/any match. It returns nil
+// if nothing matches.
+func resolve(m bootMap, arch string, userClasses []string) *url.URL {
+	for _, uc := range userClasses {
+		if u, ok := m[bootKey{arch: arch, userClass: uc}]; ok {
+			return u
+		}
+	}
+	for _, uc := range userClasses {
+		if u, ok := m[bootKey{arch: archDefault, userClass: uc}]; ok {
+			return u
+		}
+	}
+	if u, ok := m[bootKey{arch: arch, userClass: ""}]; ok {
+		return u
+	}
+	if u, ok := m[bootKey{arch: archDefault, userClass: ""}]; ok {
+		return u
+	}
+	return nil
+}
+
+func pxeHandler4(m bootMap, req, resp *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, bool) {
+	arch := archDefault
+	if archs := req.ClientArch(); len(archs) > 0 {
+		arch = archString(archs[0])
+	}
+	u := resolve(m, arch, userClasses4(req))
+	if u == nil {
+		log.Debugf("no pxeboot entry matched arch=%s for %s, skipping", arch, req.ClientHWAddr)
+		return resp, true
+	}
+
+	switch u.Scheme {
+	case "http", "https", "ftp":
+		if req.IsOptionRequested(dhcpv4.OptionBootfileName) {
+			resp.Options.Update(dhcpv4.OptBootFileName(u.String()))
+		}
+	default:
+		if ip := net.ParseIP(u.Hostname()); ip != nil && ip.To4() != nil {
+			resp.ServerIPAddr = ip.To4()
+		}
+		if req.IsOptionRequested(dhcpv4.OptionTFTPServerName) {
+			resp.Options.Update(dhcpv4.OptTFTPServerName(u.Host))
+		}
+		if req.IsOptionRequested(dhcpv4.OptionBootfileName) {
+			resp.Options.Update(dhcpv4.OptBootFileName(u.Path))
+		}
+	}
+
+	log.Debugf("resolved pxeboot URL %s for arch=%s client=%s", u, arch, req.ClientHWAddr)
+	return resp, true
+}
+
+// clientArchTypes6 extracts the architecture types advertised in DHCPv6
+// OPT_CLIENT_ARCH_TYPE (option 61, RFC 5970), a list of 16-bit values.
+func clientArchTypes6(req dhcpv6.DHCPv6) []iana.Arch {
+	data := req.GetOneOption(dhcpv6.OptionClientArchType)
+	if data == nil {
+		return nil
+	}
+	raw := data.ToBytes()
+	archs := make([]iana.Arch, 0, len(raw)/2)
+	for i := 0; i+1 < len(raw); i += 2 {
+		archs = append(archs, iana.Arch(binary.BigEndian.Uint16(raw[i:i+2])))
+	}
+	return archs
+}
+
+// userClasses6 extracts the user class strings advertised in DHCPv6
+// OPTION_USER_CLASS (option 15, RFC 3315: each entry is a 2-byte
+// length-prefixed string).
+func userClasses6(req dhcpv6.DHCPv6) []string {
+	data := req.GetOneOption(dhcpv6.OptionUserClass)
+	if data == nil {
+		return nil
+	}
+	raw := data.ToBytes()
+	var classes []string
+	for len(raw) >= 2 {
+		n := int(binary.BigEndian.Uint16(raw[0:2]))
+		raw = raw[2:]
+		if n == 0 || n > len(raw) {
+			break
+		}
+		classes = append(classes, string(raw[:n]))
+		raw = raw[n:]
+	}
+	return classes
+}
+
+func pxeHandler6(m bootMap, req, resp dhcpv6.DHCPv6) (dhcpv6.DHCPv6, bool) {
+	decap, err := req.GetInnerMessage()
+	if err != nil {
+		log.Errorf("Could not decapsulate request: %v", err)
+		return nil, true
+	}
+
+	arch := archDefault
+	if archs := clientArchTypes6(decap); len(archs) > 0 {
+		arch = archString(archs[0])
+	}
+	u := resolve(m, arch, userClasses6(decap))
+	if u == nil {
+		log.Debugf("no pxeboot entry matched arch=%s, skipping", arch)
+		return resp, true
+	}
+
+	opt59 := dhcpv6.OptBootFileURL(u.String())
+	for _, code := range decap.Options.RequestedOptions() {
+		if code == dhcpv6.OptionBootfileURL {
+			resp.AddOption(opt59)
+		}
+	}
+	log.Debugf("resolved pxeboot URL %s for arch=%s", u, arch)
+	return resp, true
+}
@@ -0,0 +1,190 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package pxeboot
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv6"
+	"github.com/insomniacslk/dhcp/iana"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustMAC(t *testing.T, s string) net.HardwareAddr {
+	mac, err := net.ParseMAC(s)
+	require.NoError(t, err)
+	return mac
+}
+
+func TestParseArgs(t *testing.T) {
+	m, err := parseArgs(
+		"x86legacy,,tftp://10.0.0.1/undionly.kpxe",
+		"x86,,tftp://10.0.0.1/ipxe.efi",
+		"default,iPXE,http://10.0.0.1/boot.ipxe",
+	)
+	require.NoError(t, err)
+	assert.Len(t, m, 3)
+	assert.Equal(t, "tftp", m[bootKey{arch: "x86legacy", userClass: ""}].Scheme)
+	assert.Equal(t, "http", m[bootKey{arch: archDefault, userClass: "iPXE"}].Scheme)
+}
+
+func TestParseArgsInvalid(t *testing.T) {
+	_, err := parseArgs("bogus-entry")
+	assert.Error(t, err)
+
+	_, err = parseArgs()
+	assert.Error(t, err)
+}
+
+func requestWithArchAndUserClass(t *testing.T, mac net.HardwareAddr, arch iana.Arch, userClass string) *dhcpv4.DHCPv4 {
+	req, err := dhcpv4.NewDiscovery(mac, dhcpv4.WithRequestedOptions(
+		dhcpv4.OptionTFTPServerName,
+		dhcpv4.OptionBootfileName,
+	))
+	require.NoError(t, err)
+	req.Options.Update(dhcpv4.OptClientArch(arch))
+	if userClass != "" {
+		req.Options.Update(dhcpv4.Option{
+			Code:  dhcpv4.OptionUserClassInformation,
+			Value: dhcpv4.OptionGeneric{Data: append([]byte{byte(len(userClass))}, []byte(userClass)...)},
+		})
+	}
+	return req
+}
+
+func TestPxeHandler4Stages(t *testing.T) {
+	m, err := parseArgs(
+		"x86legacy,,tftp://10.0.0.1/undionly.kpxe",
+		"x86,,tftp://10.0.0.1/ipxe.efi",
+		"arm64,,tftp://10.0.0.1/snponly-arm64.efi",
+		"default,iPXE,http://10.0.0.1/boot.ipxe",
+	)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name        string
+		arch        iana.Arch
+		userClass   string
+		wantScheme  string
+		wantHasBoot bool
+	}{
+		{"bios stage1", iana.INTEL_X86PC, "", "tftp", true},
+		{"efi x86_64 stage1", iana.EFI_X86_64, "", "tftp", true},
+		{"efi arm64 stage1", iana.EFI_ARM64, "", "tftp", true},
+		{"ipxe stage2", iana.INTEL_X86PC, "iPXE", "http", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mac := mustMAC(t, "00:11:22:33:44:55")
+			req := requestWithArchAndUserClass(t, mac, tc.arch, tc.userClass)
+			resp, err := dhcpv4.NewReplyFromRequest(req)
+			require.NoError(t, err)
+
+			result, stop := pxeHandler4(m, req, resp)
+			assert.True(t, stop)
+			require.NotNil(t, result)
+
+			if tc.wantScheme == "http" {
+				assert.True(t, result.IsOptionRequested(dhcpv4.OptionBootfileName))
+				assert.NotEmpty(t, string(result.Options.Get(dhcpv4.OptionBootfileName)))
+			} else {
+				assert.NotEmpty(t, result.ServerIPAddr)
+			}
+		})
+	}
+}
+
+func TestPxeHandler4NoMatch(t *testing.T) {
+	m, err := parseArgs("x86legacy,,tftp://10.0.0.1/undionly.kpxe")
+	require.NoError(t, err)
+
+	mac := mustMAC(t, "00:11:22:33:44:55")
+	req := requestWithArchAndUserClass(t, mac, iana.EFI_ARM64, "")
+	resp, err := dhcpv4.NewReplyFromRequest(req)
+	require.NoError(t, err)
+
+	result, stop := pxeHandler4(m, req, resp)
+	assert.True(t, stop)
+	assert.Equal(t, resp, result)
+}
+
+// requestWithArchAndUserClass6 builds a synthetic DHCPv6 Solicit,
+// encapsulated in a RelayForward the way pxeHandler6 expects (it always
+// calls GetInnerMessage), advertising the given arch and user class.
+func requestWithArchAndUserClass6(t *testing.T, mac net.HardwareAddr, arch iana.Arch, userClass string) dhcpv6.DHCPv6 {
+	inner, err := dhcpv6.NewMessage()
+	require.NoError(t, err)
+	inner.MessageType = dhcpv6.MessageTypeSolicit
+
+	archData := make([]byte, 2)
+	binary.BigEndian.PutUint16(archData, uint16(arch))
+	inner.AddOption(&dhcpv6.OptionGeneric{OptionCode: dhcpv6.OptionClientArchType, OptionData: archData})
+	inner.AddOption(dhcpv6.OptRequestedOption(dhcpv6.OptionBootfileURL))
+
+	if userClass != "" {
+		data := make([]byte, 2+len(userClass))
+		binary.BigEndian.PutUint16(data, uint16(len(userClass)))
+		copy(data[2:], userClass)
+		inner.AddOption(&dhcpv6.OptionGeneric{OptionCode: dhcpv6.OptionUserClass, OptionData: data})
+	}
+
+	relay, err := dhcpv6.EncapsulateRelay(inner, dhcpv6.MessageTypeRelayForward, net.IPv6loopback, net.IPv6loopback)
+	require.NoError(t, err)
+	return relay
+}
+
+func TestPxeHandler6Stages(t *testing.T) {
+	m, err := parseArgs(
+		"x86legacy,,tftp://[2001:db8::1]/undionly.kpxe",
+		"default,iPXE,http://[2001:db8::1]/boot.ipxe",
+	)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		arch       iana.Arch
+		userClass  string
+		wantScheme string
+	}{
+		{"bios stage1", iana.INTEL_X86PC, "", "tftp"},
+		{"ipxe stage2", iana.INTEL_X86PC, "iPXE", "http"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mac := mustMAC(t, "00:11:22:33:44:66")
+			req := requestWithArchAndUserClass6(t, mac, tc.arch, tc.userClass)
+			// The server hands handlers a plain reply message as resp,
+			// not the (possibly relayed) request.
+			resp, err := dhcpv6.NewMessage()
+			require.NoError(t, err)
+
+			result, stop := pxeHandler6(m, req, resp)
+			assert.True(t, stop)
+			require.NotNil(t, result)
+
+			decap, err := result.GetInnerMessage()
+			require.NoError(t, err)
+			opt := decap.Options.GetOne(dhcpv6.OptionBootfileURL)
+			require.NotNil(t, opt)
+			assert.Contains(t, opt.String(), tc.wantScheme)
+		})
+	}
+}
+
+func TestPxeHandler6NoMatch(t *testing.T) {
+	m, err := parseArgs("x86legacy,,tftp://[2001:db8::1]/undionly.kpxe")
+	require.NoError(t, err)
+
+	mac := mustMAC(t, "00:11:22:33:44:66")
+	req := requestWithArchAndUserClass6(t, mac, iana.EFI_ARM64, "")
+
+	result, stop := pxeHandler6(m, req, req)
+	assert.True(t, stop)
+	assert.Equal(t, req, result)
+}
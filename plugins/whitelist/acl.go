@@ -0,0 +1,241 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package whitelist
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Mode selects whether a matching MAC is allowed through (ModeAllow, the
+// default "whitelist" behaviour) or dropped (ModeDeny, "blacklist"
+// behaviour).
+type Mode string
+
+const (
+	// ModeAllow only lets matching MACs through; everything else is
+	// dropped.
+	ModeAllow Mode = "allow"
+	// ModeDeny drops matching MACs; everything else is let through.
+	ModeDeny Mode = "deny"
+)
+
+// entry is a single configured match: the leading `bits` bits of `bytes`
+// must equal the client MAC's leading bits. An exact MAC address is simply
+// an entry with bits == 48; a wildcard OUI like "00:11:22:*" or a
+// CIDR-like range "00:11:22:33:44:00/40" are entries with bits < 48.
+type entry struct {
+	raw   string
+	bytes [6]byte
+	bits  int
+}
+
+func (e entry) matches(mac net.HardwareAddr) bool {
+	if len(mac) != 6 {
+		return false
+	}
+	fullBytes := e.bits / 8
+	for i := 0; i < fullBytes; i++ {
+		if mac[i] != e.bytes[i] {
+			return false
+		}
+	}
+	if remBits := e.bits % 8; remBits > 0 {
+		mask := byte(0xFF << (8 - remBits))
+		if mac[fullBytes]&mask != e.bytes[fullBytes]&mask {
+			return false
+		}
+	}
+	return true
+}
+
+// parseEntry parses a single configured MAC entry: an exact address
+// ("aa:bb:cc:dd:ee:ff"), a wildcard OUI ("aa:bb:cc:*"), or a CIDR-like
+// prefix range ("aa:bb:cc:dd:ee:ff/24").
+func parseEntry(raw string) (entry, error) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	if s == "" {
+		return entry{}, fmt.Errorf("empty whitelist entry")
+	}
+
+	if idx := strings.Index(s, "/"); idx >= 0 {
+		mac, err := net.ParseMAC(s[:idx])
+		if err != nil {
+			return entry{}, fmt.Errorf("invalid MAC in range %q: %w", raw, err)
+		}
+		bits, err := strconv.Atoi(s[idx+1:])
+		if err != nil || bits < 0 || bits > 48 {
+			return entry{}, fmt.Errorf("invalid prefix length in range %q", raw)
+		}
+		var e entry
+		e.raw = s
+		copy(e.bytes[:], mac)
+		e.bits = bits
+		return e, nil
+	}
+
+	if strings.HasSuffix(s, ":*") {
+		octets := strings.Split(strings.TrimSuffix(s, ":*"), ":")
+		if len(octets) == 0 || len(octets) > 6 {
+			return entry{}, fmt.Errorf("invalid wildcard OUI %q", raw)
+		}
+		var e entry
+		e.raw = s
+		for i, o := range octets {
+			b, err := strconv.ParseUint(o, 16, 8)
+			if err != nil {
+				return entry{}, fmt.Errorf("invalid octet %q in %q", o, raw)
+			}
+			e.bytes[i] = byte(b)
+		}
+		e.bits = len(octets) * 8
+		return e, nil
+	}
+
+	mac, err := net.ParseMAC(s)
+	if err != nil {
+		return entry{}, fmt.Errorf("invalid MAC address %q: %w", raw, err)
+	}
+	var e entry
+	e.raw = s
+	copy(e.bytes[:], mac)
+	e.bits = 48
+	return e, nil
+}
+
+// ACL is an immutable snapshot of a whitelist/blacklist configuration. New
+// configurations (from a reload or an admin API call) are built as a new
+// ACL and swapped in atomically, so lookups never observe a partial
+// update.
+type ACL struct {
+	mode    Mode
+	entries []entry
+}
+
+// NewACL builds an ACL from a mode and a list of raw entries (exact MACs,
+// wildcard OUIs, or CIDR-like ranges).
+func NewACL(mode Mode, raw []string) (*ACL, error) {
+	if mode == "" {
+		mode = ModeAllow
+	}
+	acl := &ACL{mode: mode}
+	for _, r := range raw {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		e, err := parseEntry(r)
+		if err != nil {
+			return nil, err
+		}
+		acl.entries = append(acl.entries, e)
+	}
+	return acl, nil
+}
+
+// LoadACLFile reads one entry per line from path (blank lines and lines
+// starting with "#" are ignored) and builds an ACL in the given mode.
+func LoadACLFile(path string, mode Mode) (*ACL, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var raw []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		raw = append(raw, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewACL(mode, raw)
+}
+
+// Mode reports the ACL's allow/deny mode.
+func (a *ACL) Mode() Mode {
+	if a == nil {
+		return ModeAllow
+	}
+	return a.mode
+}
+
+// Entries returns the raw, normalized form of every configured entry.
+func (a *ACL) Entries() []string {
+	if a == nil {
+		return nil
+	}
+	out := make([]string, len(a.entries))
+	for i, e := range a.entries {
+		out[i] = e.raw
+	}
+	return out
+}
+
+func (a *ACL) contains(mac net.HardwareAddr) bool {
+	for _, e := range a.entries {
+		if e.matches(mac) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed reports whether mac should be let through. An ACL with no
+// entries imposes no restriction, regardless of mode, matching the
+// plugin's historical "no whitelist configured -> allow all" behaviour.
+func (a *ACL) Allowed(mac net.HardwareAddr) bool {
+	if a == nil || len(a.entries) == 0 {
+		return true
+	}
+	matched := a.contains(mac)
+	if a.mode == ModeDeny {
+		return !matched
+	}
+	return matched
+}
+
+// withAdded returns a new ACL with raw added to its entry list, preserving
+// mode. It is used by the admin API to mutate an ACL without racing other
+// readers of the previous snapshot.
+func (a *ACL) withAdded(raw string) (*ACL, error) {
+	e, err := parseEntry(raw)
+	if err != nil {
+		return nil, err
+	}
+	mode := ModeAllow
+	var entries []entry
+	if a != nil {
+		mode = a.mode
+		entries = append(entries, a.entries...)
+	}
+	entries = append(entries, e)
+	return &ACL{mode: mode, entries: entries}, nil
+}
+
+// withRemoved returns a new ACL with every entry whose normalized form
+// equals raw removed.
+func (a *ACL) withRemoved(raw string) *ACL {
+	if a == nil {
+		return a
+	}
+	norm := strings.ToLower(strings.TrimSpace(raw))
+	out := &ACL{mode: a.mode}
+	for _, e := range a.entries {
+		if e.raw != norm {
+			out.entries = append(out.entries, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+// Copyright 2018-present the CoreDHCP Authors. All rights reserved
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package whitelist
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// adminEntry is the wire format for GET /admin/whitelist.
+type adminEntry struct {
+	Mode    string   `json:"mode"`
+	Entries []string `json:"entries"`
+}
+
+// adminRequest is the wire format for POST /admin/whitelist.
+type adminRequest struct {
+	MAC string `json:"mac"`
+}
+
+// registerAdminHandlers wires the whitelist admin API onto mux. The admin
+// API manages both the DHCPv4 and DHCPv6 ACLs together, since operators
+// generally want a MAC added or removed on both at once.
+func registerAdminHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/whitelist", handleCollection)
+	mux.HandleFunc("/admin/whitelist/", handleItem)
+}
+
+func handleCollection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeACLs(w)
+	case http.MethodPost:
+		var req adminRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MAC == "" {
+			http.Error(w, "invalid request body, expected {\"mac\": \"...\"}", http.StatusBadRequest)
+			return
+		}
+		if err := addEntry(&aclV4, req.MAC); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := addEntry(&aclV6, req.MAC); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		writeACLs(w)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	mac := strings.TrimPrefix(r.URL.Path, "/admin/whitelist/")
+	if mac == "" {
+		http.Error(w, "missing mac in path", http.StatusBadRequest)
+		return
+	}
+	removeEntry(&aclV4, mac)
+	removeEntry(&aclV6, mac)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeACLs(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	v4, v6 := aclV4.Load(), aclV6.Load()
+	_ = json.NewEncoder(w).Encode(map[string]adminEntry{
+		"v4": {Mode: string(v4.Mode()), Entries: v4.Entries()},
+		"v6": {Mode: string(v6.Mode()), Entries: v6.Entries()},
+	})
+}
+
+// addEntry atomically adds raw to the ACL behind ptr, retrying on a
+// concurrent update.
+func addEntry(ptr *atomic.Pointer[ACL], raw string) error {
+	for {
+		old := ptr.Load()
+		neu, err := old.withAdded(raw)
+		if err != nil {
+			return err
+		}
+		if ptr.CompareAndSwap(old, neu) {
+			return nil
+		}
+	}
+}
+
+// removeEntry atomically removes every entry matching raw from the ACL
+// behind ptr, retrying on a concurrent update.
+func removeEntry(ptr *atomic.Pointer[ACL], raw string) {
+	for {
+		old := ptr.Load()
+		neu := old.withRemoved(normalizeMAC(raw))
+		if ptr.CompareAndSwap(old, neu) {
+			return
+		}
+	}
+}
@@ -2,17 +2,50 @@
 // This source code is licensed under the MIT license found in the
 // LICENSE file in the root directory of this source tree.
 
-// Package whitelist implements a MAC address whitelist plugin for CoreDHCP.
-// Only MAC addresses listed in the configuration will be allowed to receive IP assignments.
-// If no whitelist is configured, there are no restrictions.
+// Package whitelist implements a MAC address allow/deny-list plugin for
+// CoreDHCP. In its default "allow" mode, only MAC addresses matching the
+// configured ACL are let through (a whitelist); in "deny" mode, matching
+// addresses are dropped instead (a blacklist). An ACL with no entries at
+// all imposes no restriction.
+//
+// DHCPv4 and DHCPv6 each get their own independently configured ACL, held
+// behind an atomic.Pointer so that a reload never races a lookup. The ACL
+// can be reloaded from a file on SIGHUP, or managed live through an HTTP
+// admin API:
+//
+//	GET    /admin/whitelist       list the current mode and entries
+//	POST   /admin/whitelist       add an entry, body: {"mac": "<entry>"}
+//	DELETE /admin/whitelist/{mac} remove an entry
+//
+// Plugin arguments are either "key=value" configuration knobs or bare
+// entries (exact MACs, wildcard OUIs like "00:11:22:*", or CIDR-like
+// ranges like "00:11:22:33:44:00/40"). Recognized knobs:
+//
+//	mode=allow|deny   ACL mode, default "allow"
+//	file=<path>       load entries from a file, reloaded on SIGHUP
+//	admin=<addr>      serve the admin API on addr, e.g. "127.0.0.1:8080"
+//
+// Example usage:
+//
+// server4:
+//   - plugins:
+//   - whitelist: mode=deny file=/etc/coredhcp/denylist-v4.txt admin=127.0.0.1:8080 00:11:22:*
 package whitelist
 
 import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 
 	"github.com/insomniacslk/dhcp/dhcpv4"
 	"github.com/insomniacslk/dhcp/dhcpv6"
 	"github.com/toujourser/coredhcp/handler"
+	"github.com/toujourser/coredhcp/hooks"
 	"github.com/toujourser/coredhcp/logger"
 	"github.com/toujourser/coredhcp/plugins"
 )
@@ -26,99 +59,221 @@ var Plugin = plugins.Plugin{
 	Setup4: setup4,
 }
 
-// whitelistedMACs holds the list of MAC addresses that are allowed to receive IP assignments
-var whitelistedMACs map[string]bool
+var (
+	aclV4 atomic.Pointer[ACL]
+	aclV6 atomic.Pointer[ACL]
+
+	fileV4, fileV6 string
+	filesMu        sync.Mutex
+
+	sighupOnce sync.Once
+	adminOnce  sync.Once
+)
 
 // Handler6 handles DHCPv6 packets for the whitelist plugin
 func Handler6(req, resp dhcpv6.DHCPv6) (dhcpv6.DHCPv6, bool) {
-	// If no whitelist is configured, allow all
-	if whitelistedMACs == nil || len(whitelistedMACs) == 0 {
-		return resp, false
-	}
+	rlog := log.WithRequest6(req)
+	acl := aclV6.Load()
 
 	mac, err := dhcpv6.ExtractMAC(req)
 	if err != nil {
-		log.Warningf("Could not extract MAC address from DHCPv6 packet: %v", err)
+		rlog.Warningf("Could not extract MAC address from DHCPv6 packet: %v", err)
 		// Drop the request if we can't extract MAC address
 		return nil, true
 	}
 
-	macStr := strings.ToLower(mac.String())
-	if !whitelistedMACs[macStr] {
-		log.Infof("MAC address %s is not in whitelist, dropping request", macStr)
-		// Drop the request if MAC is not in whitelist
+	if !acl.Allowed(mac) {
+		rlog.Infof("MAC address %s denied by %s ACL, dropping request", mac, acl.Mode())
+		hooks.Emit(hooks.Event{
+			Type:    hooks.Drop,
+			MAC:     mac.String(),
+			Plugins: []string{"whitelist"},
+			Reason:  fmt.Sprintf("denied by %s ACL", acl.Mode()),
+		})
 		return nil, true
 	}
 
-	log.Debugf("MAC address %s is in whitelist, allowing request", macStr)
+	rlog.Debugf("MAC address %s allowed by %s ACL", mac, acl.Mode())
 	return resp, false
 }
 
 // Handler4 handles DHCPv4 packets for the whitelist plugin
 func Handler4(req, resp *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, bool) {
-	// If no whitelist is configured, allow all
-	if whitelistedMACs == nil || len(whitelistedMACs) == 0 {
-		return resp, false
-	}
+	rlog := log.WithRequest4(req)
+	acl := aclV4.Load()
 
-	macStr := strings.ToLower(req.ClientHWAddr.String())
-	log.Printf("[DHCP-whitelist] MAC address %s is in whitelist, allowing request", macStr)
-	if !whitelistedMACs[macStr] {
-		log.Infof("MAC address %s is not in whitelist, dropping request", macStr)
-		// Drop the request if MAC is not in whitelist
+	if !acl.Allowed(req.ClientHWAddr) {
+		rlog.Infof("MAC address %s denied by %s ACL, dropping request", req.ClientHWAddr, acl.Mode())
+		hooks.Emit(hooks.Event{
+			Type:    hooks.Drop,
+			MAC:     req.ClientHWAddr.String(),
+			XID:     req.TransactionID.String(),
+			Plugins: []string{"whitelist"},
+			Reason:  fmt.Sprintf("denied by %s ACL", acl.Mode()),
+		})
 		return nil, true
 	}
 
-	log.Debugf("MAC address %s is in whitelist, allowing request", macStr)
+	rlog.Debugf("MAC address %s allowed by %s ACL", req.ClientHWAddr, acl.Mode())
 	return resp, false
 }
 
-func setup6(args ...string) (handler.Handler6, error) {
-	log.Printf("[DHCP-whitelist] loading `whitelist` plugin for DHCPv6")
-	log.Printf("[DHCP-whitelist] Args length=%d, content=%+v", len(args), args)
-
-	// Parse MAC addresses from args
-	whitelistedMACs = make(map[string]bool)
-	for i, arg := range args {
-		log.Printf("Processing arg[%d]: '%s'", i, arg)
-		mac := strings.ToLower(strings.TrimSpace(arg))
-		// Validate MAC address format (simple validation)
-		if len(mac) > 0 {
-			log.Printf("[DHCP-whitelist] Adding MAC to whitelist: '%s'", mac)
-			whitelistedMACs[mac] = true
-		} else {
-			log.Printf("[DHCP-whitelist] Skipping empty MAC address at arg[%d]", i)
+// setupArgs are the parsed "key=value" knobs recognized in plugin args;
+// anything else is treated as a bare ACL entry.
+type setupArgs struct {
+	mode    Mode
+	file    string
+	admin   string
+	entries []string
+}
+
+func parseSetupArgs(args []string) (setupArgs, error) {
+	sa := setupArgs{mode: ModeAllow}
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "mode="):
+			switch Mode(strings.TrimPrefix(arg, "mode=")) {
+			case ModeAllow, ModeDeny:
+				sa.mode = Mode(strings.TrimPrefix(arg, "mode="))
+			default:
+				return sa, fmt.Errorf("invalid mode %q, want allow or deny", arg)
+			}
+		case strings.HasPrefix(arg, "file="):
+			sa.file = strings.TrimPrefix(arg, "file=")
+		case strings.HasPrefix(arg, "admin="):
+			sa.admin = strings.TrimPrefix(arg, "admin=")
+		default:
+			sa.entries = append(sa.entries, arg)
 		}
 	}
+	return sa, nil
+}
+
+func buildACL(sa setupArgs) (*ACL, error) {
+	acl, err := NewACL(sa.mode, sa.entries)
+	if err != nil {
+		return nil, err
+	}
+	if sa.file != "" {
+		fromFile, err := LoadACLFile(sa.file, sa.mode)
+		if err != nil {
+			return nil, fmt.Errorf("loading whitelist file %q: %w", sa.file, err)
+		}
+		merged, err := NewACL(sa.mode, append(acl.Entries(), fromFile.Entries()...))
+		if err != nil {
+			return nil, err
+		}
+		acl = merged
+	}
+	return acl, nil
+}
+
+func setup6(args ...string) (handler.Handler6, error) {
+	sa, err := parseSetupArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	acl, err := buildACL(sa)
+	if err != nil {
+		return nil, err
+	}
+	aclV6.Store(acl)
+
+	filesMu.Lock()
+	fileV6 = sa.file
+	filesMu.Unlock()
+
+	startReloadWatcher()
+	if sa.admin != "" {
+		startAdminServer(sa.admin)
+	}
 
-	log.Printf("[DHCP-whitelist] Final whitelistedMACs content: %+v, %d", whitelistedMACs, len(whitelistedMACs))
+	log.Infof("loaded whitelist plugin for DHCPv6: mode=%s entries=%d", acl.Mode(), len(acl.Entries()))
 	return Handler6, nil
 }
 
 func setup4(args ...string) (handler.Handler4, error) {
-	log.Printf("[DHCP-whitelist] loading `whitelist` plugin for DHCPv6")
-	log.Printf("[DHCP-whitelist] Args length=%d, content=%+v", len(args), args)
-
-	// For DHCPv4, we use the same whitelist as DHCPv6
-	// If this is the first setup call, parse the MAC addresses
-	if whitelistedMACs == nil {
-		log.Printf("[DHCP-whitelist] Initializing whitelistedMACs map")
-		whitelistedMACs = make(map[string]bool)
-		for i, arg := range args {
-			log.Printf("[DHCP-whitelist] Processing arg[%d]: '%s'", i, arg)
-			mac := strings.ToLower(strings.TrimSpace(arg))
-			// Validate MAC address format (simple validation)
-			if len(mac) > 0 {
-				log.Printf("[DHCP-whitelist] Adding MAC to whitelist: '%s'", mac)
-				whitelistedMACs[mac] = true
-			} else {
-				log.Printf("[DHCP-whitelist] Skipping empty MAC address at arg[%d]", i)
+	sa, err := parseSetupArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	acl, err := buildACL(sa)
+	if err != nil {
+		return nil, err
+	}
+	aclV4.Store(acl)
+
+	filesMu.Lock()
+	fileV4 = sa.file
+	filesMu.Unlock()
+
+	startReloadWatcher()
+	if sa.admin != "" {
+		startAdminServer(sa.admin)
+	}
+
+	log.Infof("loaded whitelist plugin for DHCPv4: mode=%s entries=%d", acl.Mode(), len(acl.Entries()))
+	return Handler4, nil
+}
+
+// startReloadWatcher starts, once per process, a goroutine that reloads
+// the v4/v6 ACLs from their configured files whenever the process
+// receives SIGHUP.
+func startReloadWatcher() {
+	sighupOnce.Do(func() {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, syscall.SIGHUP)
+		go func() {
+			for range ch {
+				reloadFromFiles()
 			}
+		}()
+	})
+}
+
+// reloadFromFiles reloads whichever of the v4/v6 ACLs have a configured
+// file backing them. It is a plain package-level function so that tests
+// can call it directly instead of sending a real signal.
+func reloadFromFiles() {
+	filesMu.Lock()
+	v4, v6 := fileV4, fileV6
+	filesMu.Unlock()
+
+	if v4 != "" {
+		if acl, err := LoadACLFile(v4, aclV4.Load().Mode()); err != nil {
+			log.Errorf("reloading whitelist file %q: %v", v4, err)
+		} else {
+			aclV4.Store(acl)
+			log.Infof("reloaded DHCPv4 whitelist from %s: %d entries", v4, len(acl.Entries()))
+		}
+	}
+	if v6 != "" {
+		if acl, err := LoadACLFile(v6, aclV6.Load().Mode()); err != nil {
+			log.Errorf("reloading whitelist file %q: %v", v6, err)
+		} else {
+			aclV6.Store(acl)
+			log.Infof("reloaded DHCPv6 whitelist from %s: %d entries", v6, len(acl.Entries()))
 		}
-	} else {
-		log.Printf("[DHCP-whitelist] whitelistedMACs already initialized with %d entries", len(whitelistedMACs))
 	}
+}
 
-	log.Printf("[DHCP-whitelist] Final whitelistedMACs content: %+v, %d", whitelistedMACs, len(whitelistedMACs))
-	return Handler4, nil
+// startAdminServer starts, once per process, the HTTP admin API on addr.
+func startAdminServer(addr string) {
+	adminOnce.Do(func() {
+		mux := http.NewServeMux()
+		registerAdminHandlers(mux)
+		srv := &http.Server{Addr: addr, Handler: mux}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Errorf("whitelist admin server stopped: %v", err)
+			}
+		}()
+		log.Infof("whitelist admin API listening on %s", addr)
+	})
+}
+
+// normalizeMAC is a small helper shared with the admin handlers to fold a
+// path/body value into the canonical lowercase form used for comparisons.
+func normalizeMAC(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
 }
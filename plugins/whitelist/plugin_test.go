@@ -6,78 +6,129 @@ package whitelist
 
 import (
 	"net"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/insomniacslk/dhcp/dhcpv4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWhitelistSetup(t *testing.T) {
-	// Test DHCPv4 setup
 	handler4, err := setup4("00:11:22:33:44:55", "aa:bb:cc:dd:ee:ff")
 	assert.NoError(t, err)
 	assert.NotNil(t, handler4)
 
-	// Test DHCPv6 setup
 	handler6, err := setup6("00:11:22:33:44:55", "aa:bb:cc:dd:ee:ff")
 	assert.NoError(t, err)
 	assert.NotNil(t, handler6)
 
-	// Check that the whitelist was populated correctly
-	assert.Equal(t, 2, len(whitelistedMACs))
-	assert.True(t, whitelistedMACs["00:11:22:33:44:55"])
-	assert.True(t, whitelistedMACs["aa:bb:cc:dd:ee:ff"])
+	assert.Equal(t, 2, len(aclV4.Load().Entries()))
+	assert.Equal(t, 2, len(aclV6.Load().Entries()))
 }
 
 func TestWhitelistHandler4(t *testing.T) {
-	// Setup whitelist with specific MAC addresses
 	_, err := setup4("00:11:22:33:44:55", "aa:bb:cc:dd:ee:ff")
-	assert.NoError(t, err)
+	require.NoError(t, err)
 
-	// Create a DHCPv4 request with a whitelisted MAC
 	mac, err := net.ParseMAC("00:11:22:33:44:55")
-	assert.NoError(t, err)
+	require.NoError(t, err)
 	req, err := dhcpv4.NewDiscovery(mac)
-	assert.NoError(t, err)
-
-	// Process the request - should be allowed
+	require.NoError(t, err)
 	resp, err := dhcpv4.NewReplyFromRequest(req)
-	assert.NoError(t, err)
+	require.NoError(t, err)
 
 	resultResp, stop := Handler4(req, resp)
-	assert.False(t, stop) // Should not stop processing
+	assert.False(t, stop)
 	assert.NotNil(t, resultResp)
 
-	// Create a DHCPv4 request with a non-whitelisted MAC
 	mac2, err := net.ParseMAC("00:00:00:00:00:00")
-	assert.NoError(t, err)
+	require.NoError(t, err)
 	req2, err := dhcpv4.NewDiscovery(mac2)
-	assert.NoError(t, err)
-
-	// Process the request - should be blocked
+	require.NoError(t, err)
 	resp2, err := dhcpv4.NewReplyFromRequest(req2)
-	assert.NoError(t, err)
+	require.NoError(t, err)
 
 	resultResp2, stop2 := Handler4(req2, resp2)
-	assert.True(t, stop2) // Should stop processing (drop request)
+	assert.True(t, stop2)
 	assert.Nil(t, resultResp2)
 
-	// Test with empty whitelist (should allow all)
-	whitelistedMACs = make(map[string]bool) // Clear whitelist
-	resp3, err := dhcpv4.NewReplyFromRequest(req)
-	assert.NoError(t, err)
-
-	resultResp3, stop3 := Handler4(req, resp3)
-	assert.False(t, stop3) // Should not stop processing
+	// Clearing the ACL should allow all again.
+	_, err = setup4()
+	require.NoError(t, err)
+	resp3, err := dhcpv4.NewReplyFromRequest(req2)
+	require.NoError(t, err)
+	resultResp3, stop3 := Handler4(req2, resp3)
+	assert.False(t, stop3)
 	assert.NotNil(t, resultResp3)
 }
 
+func TestWildcardOUIMatch(t *testing.T) {
+	acl, err := NewACL(ModeAllow, []string{"00:11:22:*"})
+	require.NoError(t, err)
+
+	in, err := net.ParseMAC("00:11:22:33:44:55")
+	require.NoError(t, err)
+	out, err := net.ParseMAC("00:11:23:33:44:55")
+	require.NoError(t, err)
+
+	assert.True(t, acl.Allowed(in))
+	assert.False(t, acl.Allowed(out))
+}
+
+func TestCIDRLikeRangeMatch(t *testing.T) {
+	acl, err := NewACL(ModeAllow, []string{"00:11:22:00:00:00/20"})
+	require.NoError(t, err)
+
+	in, err := net.ParseMAC("00:11:2f:33:44:55") // top 20 bits still match
+	require.NoError(t, err)
+	out, err := net.ParseMAC("00:11:80:33:44:55")
+	require.NoError(t, err)
+
+	assert.True(t, acl.Allowed(in))
+	assert.False(t, acl.Allowed(out))
+}
+
+func TestModeSwitch(t *testing.T) {
+	mac, err := net.ParseMAC("00:11:22:33:44:55")
+	require.NoError(t, err)
+	other, err := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	require.NoError(t, err)
+
+	allow, err := NewACL(ModeAllow, []string{"00:11:22:33:44:55"})
+	require.NoError(t, err)
+	assert.True(t, allow.Allowed(mac))
+	assert.False(t, allow.Allowed(other))
+
+	deny, err := NewACL(ModeDeny, []string{"00:11:22:33:44:55"})
+	require.NoError(t, err)
+	assert.False(t, deny.Allowed(mac))
+	assert.True(t, deny.Allowed(other))
+}
+
+func TestHotReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "acl.txt")
+	require.NoError(t, os.WriteFile(path, []byte("00:11:22:33:44:55\n"), 0o644))
+
+	_, err := setup4("file=" + path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"00:11:22:33:44:55"}, aclV4.Load().Entries())
+
+	require.NoError(t, os.WriteFile(path, []byte("00:11:22:33:44:55\naa:bb:cc:dd:ee:ff\n"), 0o644))
+	reloadFromFiles()
+
+	assert.ElementsMatch(t, []string{"00:11:22:33:44:55", "aa:bb:cc:dd:ee:ff"}, aclV4.Load().Entries())
+}
+
 func TestWhitelistHandler6(t *testing.T) {
-	// Setup whitelist with specific MAC addresses
 	_, err := setup6("00:11:22:33:44:55", "aa:bb:cc:dd:ee:ff")
-	assert.NoError(t, err)
+	require.NoError(t, err)
 
-	// Note: Testing DHCPv6 handler is more complex due to the nature of DHCPv6 packets
-	// and MAC extraction. In a real scenario, we would need to mock a complete DHCPv6
-	// exchange to properly test this.
+	// Testing the DHCPv6 handler end-to-end requires a full DHCPv6
+	// exchange to extract a MAC from; that matching logic is covered by
+	// the ACL-level tests above, so here we only check setup produced a
+	// usable ACL.
+	assert.Equal(t, 2, len(aclV6.Load().Entries()))
 }